@@ -0,0 +1,483 @@
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	"github.com/renne444/musig-go/crypto"
+)
+
+func newFlagSet(name string) *flag.FlagSet {
+	return flag.NewFlagSet(name, flag.ContinueOnError)
+}
+
+// required returns an error naming the first of names that was not set
+func required(fs *flag.FlagSet, names ...string) error {
+	for _, name := range names {
+		if fs.Lookup(name).Value.String() == "" {
+			return errors.Errorf("flag -%s is required", name)
+		}
+	}
+	return nil
+}
+
+func cmdKeygen(args []string, stdout io.Writer) error {
+	fs := newFlagSet("keygen")
+	out := fs.String("out", "", "private key file to write")
+	pub := fs.String("pub", "", "public key file to write for the cosigners")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := required(fs, "out"); err != nil {
+		return err
+	}
+
+	key, err := crypto.NewPrivateKey()
+	if err != nil {
+		return err
+	}
+	publicKey := hex.EncodeToString(key.PublicKey.Bytes())
+	if err := writeFile(*out, keyFile{
+		Type:       typeKey,
+		PrivateKey: encodeScalar(key.D),
+		PublicKey:  publicKey,
+	}, true); err != nil {
+		return err
+	}
+	if *pub != "" {
+		if err := writeFile(*pub, publicKeyFile{Type: typePublicKey, PublicKey: publicKey}, false); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintln(stdout, publicKey)
+	return nil
+}
+
+// loadPublicKey reads a public key, key or group file, for a group it is the aggregate key
+func loadPublicKey(path string) ([]byte, error) {
+	raw, typ, err := readRaw(path)
+	if err != nil {
+		return nil, err
+	}
+	var v struct {
+		PublicKey    string `json:"public_key"`
+		AggregateKey string `json:"aggregate_key"`
+	}
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return nil, errors.Wrapf(err, "read %s", path)
+	}
+	switch typ {
+	case typePublicKey, typeKey:
+		return decodeHex("public key", v.PublicKey)
+	case typeGroup:
+		return decodeHex("aggregate key", v.AggregateKey)
+	}
+	return nil, errors.Errorf("%s is a %q file, want a public key", path, typ)
+}
+
+func cmdAggregate(args []string, stdout io.Writer) error {
+	fs := newFlagSet("aggregate")
+	out := fs.String("out", "", "group file to write")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := required(fs, "out"); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		return errors.New("no public key files given")
+	}
+
+	var pubkeys [][]byte
+	for _, path := range fs.Args() {
+		pubkey, err := loadPublicKey(path)
+		if err != nil {
+			return err
+		}
+		pubkeys = append(pubkeys, pubkey)
+	}
+	key, err := crypto.AggregateKeys(pubkeys)
+	if err != nil {
+		return err
+	}
+
+	group := groupFile{Type: typeGroup, AggregateKey: hex.EncodeToString(key.Bytes())}
+	for _, pubkey := range key.PubKeys {
+		group.PublicKeys = append(group.PublicKeys, hex.EncodeToString(pubkey))
+	}
+	if err := writeFile(*out, group, false); err != nil {
+		return err
+	}
+	fmt.Fprintln(stdout, group.AggregateKey)
+	return nil
+}
+
+// loadGroup recomputes the aggregate key of a group file and checks it against the recorded one
+func loadGroup(path string) (*crypto.AggregateKey, error) {
+	var group groupFile
+	if err := readFile(path, typeGroup, &group); err != nil {
+		return nil, err
+	}
+	var pubkeys [][]byte
+	for _, s := range group.PublicKeys {
+		pubkey, err := decodeHex("public key", s)
+		if err != nil {
+			return nil, err
+		}
+		pubkeys = append(pubkeys, pubkey)
+	}
+	key, err := crypto.AggregateKeys(pubkeys, crypto.KeepKeyOrder())
+	if err != nil {
+		return nil, err
+	}
+	if hex.EncodeToString(key.Bytes()) != group.AggregateKey {
+		return nil, errors.Errorf("%s: aggregate key does not match the public keys", path)
+	}
+	return key, nil
+}
+
+func loadPrivateKey(path string) (*big.Int, error) {
+	var key keyFile
+	if err := readFile(path, typeKey, &key); err != nil {
+		return nil, err
+	}
+	return decodeScalar("private key", key.PrivateKey)
+}
+
+func cmdNonce(args []string, stdout io.Writer) error {
+	fs := newFlagSet("nonce")
+	keyPath := fs.String("key", "", "private key file")
+	groupPath := fs.String("group", "", "group file")
+	messagePath := fs.String("message", "", "file with the message to sign")
+	out := fs.String("out", "", "secret nonce file to write")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := required(fs, "key", "group", "message", "out"); err != nil {
+		return err
+	}
+
+	privKey, err := loadPrivateKey(*keyPath)
+	if err != nil {
+		return err
+	}
+	key, err := loadGroup(*groupPath)
+	if err != nil {
+		return err
+	}
+	message, err := os.ReadFile(*messagePath)
+	if err != nil {
+		return err
+	}
+	index := key.Index(crypto.ScalarBaseMult(privKey).Bytes())
+	if index < 0 {
+		return errors.New("public key of signer not in group")
+	}
+	r, err := crypto.DeriveNonce(privKey, message, key.Bytes())
+	if err != nil {
+		return err
+	}
+	return writeFile(*out, nonceFile{
+		Type:         typeNonce,
+		Index:        index,
+		AggregateKey: hex.EncodeToString(key.Bytes()),
+		Message:      hex.EncodeToString(message),
+		Nonce:        encodeScalar(r),
+	}, true)
+}
+
+// signerFlags are the inputs every step of a signer needs
+type signerFlags struct {
+	fs                     *flag.FlagSet
+	key, group, nonce, out *string
+}
+
+func newSignerFlags(fs *flag.FlagSet) signerFlags {
+	return signerFlags{
+		fs:    fs,
+		key:   fs.String("key", "", "private key file"),
+		group: fs.String("group", "", "group file"),
+		nonce: fs.String("nonce", "", "secret nonce file from the nonce command"),
+		out:   fs.String("out", "", "file to write"),
+	}
+}
+
+// session rebuilds the signing session of the signer from its files
+func (f signerFlags) session(opts ...crypto.SessionOption) (*crypto.Session, error) {
+	if err := required(f.fs, "key", "group", "nonce", "out"); err != nil {
+		return nil, err
+	}
+	privKey, err := loadPrivateKey(*f.key)
+	if err != nil {
+		return nil, err
+	}
+	key, err := loadGroup(*f.group)
+	if err != nil {
+		return nil, err
+	}
+	var nonce nonceFile
+	if err := readFile(*f.nonce, typeNonce, &nonce); err != nil {
+		return nil, err
+	}
+	if nonce.AggregateKey != hex.EncodeToString(key.Bytes()) {
+		return nil, errors.New("nonce was made for another group")
+	}
+	message, err := decodeHex("message", nonce.Message)
+	if err != nil {
+		return nil, err
+	}
+	r, err := decodeScalar("nonce", nonce.Nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	session, err := crypto.NewSession(key, privKey, message, append(opts, crypto.WithNonce(r))...)
+	if err != nil {
+		return nil, err
+	}
+	if session.Index() != nonce.Index {
+		return nil, errors.New("nonce belongs to another signer")
+	}
+	return session, nil
+}
+
+// readRoundFiles sorts the files of the cosigners by type, every other type is an error
+func readRoundFiles(paths []string, types ...string) (map[string][]roundFile, error) {
+	ret := make(map[string][]roundFile)
+	for _, path := range paths {
+		raw, typ, err := readRaw(path)
+		if err != nil {
+			return nil, err
+		}
+		known := false
+		for _, t := range types {
+			known = known || t == typ
+		}
+		if !known {
+			return nil, errors.Errorf("%s: unexpected %q file", path, typ)
+		}
+		var round roundFile
+		if err := json.Unmarshal(raw, &round); err != nil {
+			return nil, errors.Wrapf(err, "read %s", path)
+		}
+		ret[typ] = append(ret[typ], round)
+	}
+	return ret, nil
+}
+
+func addCommitments(session *crypto.Session, rounds []roundFile) error {
+	for _, round := range rounds {
+		if round.Index == session.Index() {
+			continue
+		}
+		if err := session.AddCommitment(round.Index, round.Value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func addReveals(session *crypto.Session, rounds []roundFile) error {
+	for _, round := range rounds {
+		if round.Index == session.Index() {
+			continue
+		}
+		Ri, err := decodeHex("reveal", round.Value)
+		if err != nil {
+			return err
+		}
+		if err := session.AddReveal(round.Index, Ri); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func cmdCommit(args []string, stdout io.Writer) error {
+	fs := newFlagSet("commit")
+	f := newSignerFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	session, err := f.session()
+	if err != nil {
+		return err
+	}
+	return writeFile(*f.out, roundFile{Type: typeCommitment, Index: session.Index(), Value: session.Commitment()}, false)
+}
+
+func cmdReveal(args []string, stdout io.Writer) error {
+	fs := newFlagSet("reveal")
+	f := newSignerFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	session, err := f.session()
+	if err != nil {
+		return err
+	}
+	rounds, err := readRoundFiles(fs.Args(), typeCommitment)
+	if err != nil {
+		return err
+	}
+	if err := addCommitments(session, rounds[typeCommitment]); err != nil {
+		return err
+	}
+	Ri, err := session.Reveal()
+	if err != nil {
+		return err
+	}
+	return writeFile(*f.out, roundFile{Type: typeReveal, Index: session.Index(), Value: hex.EncodeToString(Ri)}, false)
+}
+
+func defaultLedger() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".musig", "nonces")
+}
+
+func cmdPartialSign(args []string, stdout io.Writer) error {
+	fs := newFlagSet("partial-sign")
+	f := newSignerFlags(fs)
+	ledger := fs.String("ledger", defaultLedger(), "directory of the used nonces")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := required(fs, "ledger"); err != nil {
+		return err
+	}
+	store, err := crypto.NewFileNonceStore(*ledger)
+	if err != nil {
+		return err
+	}
+	session, err := f.session(crypto.WithNonceStore(store))
+	if err != nil {
+		return err
+	}
+	rounds, err := readRoundFiles(fs.Args(), typeCommitment, typeReveal)
+	if err != nil {
+		return err
+	}
+	if err := addCommitments(session, rounds[typeCommitment]); err != nil {
+		return err
+	}
+	if err := addReveals(session, rounds[typeReveal]); err != nil {
+		return err
+	}
+	si, err := session.PartialSign()
+	if err != nil {
+		return err
+	}
+	return writeFile(*f.out, roundFile{Type: typePartial, Index: session.Index(), Value: encodeScalar(si)}, false)
+}
+
+func cmdCombine(args []string, stdout io.Writer) error {
+	fs := newFlagSet("combine")
+	groupPath := fs.String("group", "", "group file")
+	messagePath := fs.String("message", "", "file with the signed message")
+	out := fs.String("out", "", "signature file to write")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := required(fs, "group", "message", "out"); err != nil {
+		return err
+	}
+	key, err := loadGroup(*groupPath)
+	if err != nil {
+		return err
+	}
+	message, err := os.ReadFile(*messagePath)
+	if err != nil {
+		return err
+	}
+	rounds, err := readRoundFiles(fs.Args(), typeReveal, typePartial)
+	if err != nil {
+		return err
+	}
+
+	Rs := make([][]byte, len(key.PubKeys))
+	partials := make([]*big.Int, len(key.PubKeys))
+	for _, round := range rounds[typeReveal] {
+		if round.Index < 0 || round.Index >= len(Rs) || Rs[round.Index] != nil {
+			return errors.Errorf("unexpected reveal of signer %d", round.Index)
+		}
+		if Rs[round.Index], err = decodeHex("reveal", round.Value); err != nil {
+			return err
+		}
+	}
+	for _, round := range rounds[typePartial] {
+		if round.Index < 0 || round.Index >= len(partials) || partials[round.Index] != nil {
+			return errors.Errorf("unexpected partial signature of signer %d", round.Index)
+		}
+		if partials[round.Index], err = decodeScalar("partial signature", round.Value); err != nil {
+			return err
+		}
+	}
+	for i := range Rs {
+		if Rs[i] == nil || partials[i] == nil {
+			return errors.Errorf("reveal or partial signature of signer %d missing", i)
+		}
+	}
+
+	signature, err := crypto.CombinePartialSignatures(key, Rs, partials, message)
+	if err != nil {
+		return err
+	}
+	encoded := hex.EncodeToString(signature[:])
+	if err := writeFile(*out, signatureFile{Type: typeSignature, Signature: encoded}, false); err != nil {
+		return err
+	}
+	fmt.Fprintln(stdout, encoded)
+	return nil
+}
+
+func cmdVerify(args []string, stdout io.Writer) error {
+	fs := newFlagSet("verify")
+	keyPath := fs.String("key", "", "group or public key file")
+	messagePath := fs.String("message", "", "file with the signed message")
+	signaturePath := fs.String("signature", "", "signature file")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := required(fs, "key", "message", "signature"); err != nil {
+		return err
+	}
+	pubkey, err := loadPublicKey(*keyPath)
+	if err != nil {
+		return err
+	}
+	P, err := crypto.ParsePoint(pubkey)
+	if err != nil {
+		return err
+	}
+	message, err := os.ReadFile(*messagePath)
+	if err != nil {
+		return err
+	}
+	var sig signatureFile
+	if err := readFile(*signaturePath, typeSignature, &sig); err != nil {
+		return err
+	}
+	raw, err := decodeHex("signature", sig.Signature)
+	if err != nil {
+		return err
+	}
+	signature, err := crypto.ParseSignature(raw)
+	if err != nil {
+		return err
+	}
+	if ok, err := crypto.Verify(P.X, P.Y, signature.Rx, signature.S, message); !ok {
+		return err
+	}
+	fmt.Fprintln(stdout, "signature ok")
+	return nil
+}
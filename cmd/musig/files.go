@@ -0,0 +1,129 @@
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"math/big"
+	"os"
+
+	"github.com/pkg/errors"
+)
+
+// Every file carries its type, so the round commands take the files of the
+// cosigners in any order. Byte strings are hex, public keys and nonce points
+// are PointMarshal encoded.
+const (
+	typeKey        = "musig/key"
+	typePublicKey  = "musig/public-key"
+	typeGroup      = "musig/group"
+	typeNonce      = "musig/nonce"
+	typeCommitment = "musig/commitment"
+	typeReveal     = "musig/reveal"
+	typePartial    = "musig/partial-signature"
+	typeSignature  = "musig/signature"
+)
+
+type keyFile struct {
+	Type       string `json:"type"`
+	PrivateKey string `json:"private_key"`
+	PublicKey  string `json:"public_key"`
+}
+
+type publicKeyFile struct {
+	Type      string `json:"type"`
+	PublicKey string `json:"public_key"`
+}
+
+type groupFile struct {
+	Type         string   `json:"type"`
+	PublicKeys   []string `json:"public_keys"`
+	AggregateKey string   `json:"aggregate_key"`
+}
+
+// nonceFile holds the secret nonce r, it is bound to one message
+type nonceFile struct {
+	Type         string `json:"type"`
+	Index        int    `json:"index"`
+	AggregateKey string `json:"aggregate_key"`
+	Message      string `json:"message"`
+	Nonce        string `json:"nonce"`
+}
+
+// roundFile is a commitment, reveal or partial signature of signer Index
+type roundFile struct {
+	Type  string `json:"type"`
+	Index int    `json:"index"`
+	Value string `json:"value"`
+}
+
+type signatureFile struct {
+	Type      string `json:"type"`
+	Signature string `json:"signature"`
+}
+
+// readFile decodes path into v and checks that it has type want
+func readFile(path, want string, v interface{}) error {
+	raw, typ, err := readRaw(path)
+	if err != nil {
+		return err
+	}
+	if typ != want {
+		return errors.Errorf("%s is a %q file, want %q", path, typ, want)
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		return errors.Wrapf(err, "read %s", path)
+	}
+	return nil
+}
+
+// readRaw returns the content of path and its type
+func readRaw(path string) ([]byte, string, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, "", err
+	}
+	var head struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(raw, &head); err != nil {
+		return nil, "", errors.Wrapf(err, "read %s", path)
+	}
+	return raw, head.Type, nil
+}
+
+// writeFile stores v as indented JSON, secret files are only readable by the owner
+func writeFile(path string, v interface{}, secret bool) error {
+	raw, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	mode := os.FileMode(0644)
+	if secret {
+		mode = 0600
+	}
+	return os.WriteFile(path, append(raw, '\n'), mode)
+}
+
+func decodeHex(name, s string) ([]byte, error) {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, errors.Wrapf(err, "decode %s", name)
+	}
+	return b, nil
+}
+
+func decodeScalar(name, s string) (*big.Int, error) {
+	b, err := decodeHex(name, s)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) != 32 {
+		return nil, errors.Errorf("%s must be 32 bytes, got %d", name, len(b))
+	}
+	return new(big.Int).SetBytes(b), nil
+}
+
+func encodeScalar(k *big.Int) string {
+	var b [32]byte
+	return hex.EncodeToString(k.FillBytes(b[:]))
+}
@@ -0,0 +1,63 @@
+// Command musig runs the MuSig commit -> reveal -> partial-sign flow offline,
+// every step reads and writes JSON files that the signers pass around.
+//
+//	musig keygen -out alice.key -pub alice.pub
+//	musig aggregate -out group.json alice.pub bob.pub
+//	musig nonce -key alice.key -group group.json -message tx.bin -out alice.nonce
+//	musig commit -key alice.key -group group.json -nonce alice.nonce -out alice.commit
+//	musig reveal -key alice.key -group group.json -nonce alice.nonce -out alice.reveal bob.commit
+//	musig partial-sign -key alice.key -group group.json -nonce alice.nonce -out alice.partial bob.commit bob.reveal
+//	musig combine -group group.json -message tx.bin -out tx.sig alice.reveal bob.reveal alice.partial bob.partial
+//	musig verify -key group.json -message tx.bin -signature tx.sig
+//
+// Flags go before the files of the cosigners. partial-sign records the nonce
+// in a ledger directory and refuses to sign with it a second time.
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+var commands = map[string]func(args []string, stdout io.Writer) error{
+	"keygen":       cmdKeygen,
+	"aggregate":    cmdAggregate,
+	"nonce":        cmdNonce,
+	"commit":       cmdCommit,
+	"reveal":       cmdReveal,
+	"partial-sign": cmdPartialSign,
+	"combine":      cmdCombine,
+	"verify":       cmdVerify,
+}
+
+func usage() string {
+	var names []string
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return "usage: musig <" + strings.Join(names, "|") + "> [flags] [files]"
+}
+
+func run(args []string, stdout io.Writer) error {
+	if len(args) == 0 {
+		return errors.New(usage())
+	}
+	cmd, ok := commands[args[0]]
+	if !ok {
+		return errors.Errorf("unknown command %q\n%s", args[0], usage())
+	}
+	return cmd(args[1:], stdout)
+}
+
+func main() {
+	if err := run(os.Args[1:], os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, "musig:", err)
+		os.Exit(1)
+	}
+}
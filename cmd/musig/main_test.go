@@ -0,0 +1,107 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func runOK(t *testing.T, args ...string) string {
+	var stdout bytes.Buffer
+	if err := run(args, &stdout); err != nil {
+		t.Fatalf("musig %v: %v", args, err)
+	}
+	return stdout.String()
+}
+
+func TestSigningFlow(t *testing.T) {
+	dir := t.TempDir()
+	path := func(name string) string { return filepath.Join(dir, name) }
+	ledger := path("ledger")
+	names := []string{"alice", "bob", "carol"}
+	message := path("tx.bin")
+	if err := os.WriteFile(message, []byte("msg for signing"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var pubs []string
+	for _, name := range names {
+		runOK(t, "keygen", "-out", path(name+".key"), "-pub", path(name+".pub"))
+		pubs = append(pubs, path(name+".pub"))
+	}
+	runOK(t, append([]string{"aggregate", "-out", path("group.json")}, pubs...)...)
+
+	signer := func(cmd, name, out string) []string {
+		return []string{cmd, "-key", path(name + ".key"), "-group", path("group.json"), "-nonce", path(name + ".nonce"), "-out", path(name + out)}
+	}
+	others := func(name string, suffixes ...string) []string {
+		var ret []string
+		for _, other := range names {
+			for _, suffix := range suffixes {
+				if other != name {
+					ret = append(ret, path(other+suffix))
+				}
+			}
+		}
+		return ret
+	}
+
+	for _, name := range names {
+		runOK(t, "nonce", "-key", path(name+".key"), "-group", path("group.json"), "-message", message, "-out", path(name+".nonce"))
+		runOK(t, signer("commit", name, ".commit")...)
+	}
+	for _, name := range names {
+		runOK(t, append(signer("reveal", name, ".reveal"), others(name, ".commit")...)...)
+	}
+	for _, name := range names {
+		args := append(signer("partial-sign", name, ".partial"), "-ledger", ledger)
+		runOK(t, append(args, others(name, ".commit", ".reveal")...)...)
+	}
+
+	var rounds []string
+	for _, name := range names {
+		rounds = append(rounds, path(name+".reveal"), path(name+".partial"))
+	}
+	runOK(t, append([]string{"combine", "-group", path("group.json"), "-message", message, "-out", path("tx.sig")}, rounds...)...)
+	if out := runOK(t, "verify", "-key", path("group.json"), "-message", message, "-signature", path("tx.sig")); out != "signature ok\n" {
+		t.Errorf("verify printed %q", out)
+	}
+
+	// a second partial signature with the same nonce is refused
+	args := append(signer("partial-sign", "alice", ".partial2"), "-ledger", ledger)
+	if err := run(append(args, others("alice", ".commit", ".reveal")...), io.Discard); err == nil {
+		t.Error("partial-sign should refuse a used nonce")
+	}
+
+	if err := os.WriteFile(message, []byte("forged"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := run([]string{"verify", "-key", path("group.json"), "-message", message, "-signature", path("tx.sig")}, io.Discard); err == nil {
+		t.Error("signature verified for another message")
+	}
+}
+
+func TestCommandErrors(t *testing.T) {
+	dir := t.TempDir()
+	for _, args := range [][]string{
+		nil,
+		{"sign"},
+		{"keygen"},
+		{"nonce", "-key", filepath.Join(dir, "missing.key")},
+		{"aggregate", "-out", filepath.Join(dir, "group.json")},
+	} {
+		if err := run(args, io.Discard); err == nil {
+			t.Errorf("musig %v should fail", args)
+		}
+	}
+
+	key := filepath.Join(dir, "alice.key")
+	runOK(t, "keygen", "-out", key)
+	err := run([]string{"verify", "-key", key, "-message", key, "-signature", key}, io.Discard)
+	if err == nil || err.Error() != fmt.Sprintf("%s is a %q file, want %q", key, typeKey, typeSignature) {
+		t.Errorf("unexpected error %v", err)
+	}
+}
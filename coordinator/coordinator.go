@@ -0,0 +1,293 @@
+// Package coordinator runs the commit -> reveal -> partial-sign flow of
+// crypto.Session over a Transport. The coordinator collects the message of
+// every signer for a round, checks it and broadcasts the complete round, the
+// signers answer with their message of the next round. After the partial
+// signatures it broadcasts the final signature, or with WithAdaptor the
+// partial signatures, from which the signers assemble the pre-signature.
+package coordinator
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/renne444/musig-go/crypto"
+)
+
+// DefaultRoundTimeout is how long a round waits for the signers unless WithRoundTimeout is given
+const DefaultRoundTimeout = 30 * time.Second
+
+// SignerError reports a signer that sent a message the coordinator or a
+// cosigner can not accept. Proof is set when the signer's own signed messages
+// show the misbehaviour, anyone can check it without trusting the coordinator.
+type SignerError struct {
+	Signer int
+	Err    error
+	Proof  *crypto.BlameProof
+}
+
+func (e *SignerError) Error() string {
+	return fmt.Sprintf("signer %d: %v", e.Signer, e.Err)
+}
+
+func (e *SignerError) Unwrap() error {
+	return e.Err
+}
+
+// TimeoutError reports the signers whose message of a round did not arrive in time
+type TimeoutError struct {
+	Round   crypto.MessageType
+	Missing []int
+}
+
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("%s round timed out waiting for signers %v", e.Round, e.Missing)
+}
+
+// Coordinator drives one signing session of the signers of key over message
+type Coordinator struct {
+	transport    Transport
+	id           crypto.SessionID
+	key          *crypto.AggregateKey
+	message      []byte
+	adaptor      []byte
+	roundTimeout time.Duration
+}
+
+// Option changes the defaults of New
+type Option func(*Coordinator)
+
+// WithRoundTimeout sets how long each round waits for the signers
+func WithRoundTimeout(d time.Duration) Option {
+	return func(c *Coordinator) {
+		c.roundTimeout = d
+	}
+}
+
+// WithAdaptor makes the coordinator run signers made crypto.WithAdaptor with
+// the PointMarshal encoded point T, it is run with RunAdaptor
+func WithAdaptor(T []byte) Option {
+	return func(c *Coordinator) {
+		c.adaptor = T
+	}
+}
+
+// New returns a coordinator for the session id of the signers of key. The id
+// must be fresh for every run, messages left over from an earlier run under
+// the same id verify as well and an honest signer may be blamed for them.
+func New(transport Transport, id crypto.SessionID, key *crypto.AggregateKey, message []byte, opts ...Option) (*Coordinator, error) {
+	if transport == nil {
+		return nil, errors.New("no transport for coordinator")
+	}
+	if key == nil || len(key.PubKeys) == 0 {
+		return nil, errors.New("no public keys for coordinator")
+	}
+	c := &Coordinator{
+		transport:    transport,
+		id:           id,
+		key:          key,
+		message:      message,
+		roundTimeout: DefaultRoundTimeout,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.roundTimeout <= 0 {
+		return nil, errors.New("round timeout must be positive")
+	}
+	if c.adaptor != nil {
+		if _, err := crypto.ParsePoint(c.adaptor); err != nil {
+			return nil, errors.Wrap(err, "adaptor point")
+		}
+	}
+	return c, nil
+}
+
+// Run collects the three rounds and returns the final signature once it is
+// broadcast. Every message must be signed by its sender. A *SignerError or
+// *TimeoutError names the signers that held the session up.
+func (c *Coordinator) Run(ctx context.Context) ([64]byte, error) {
+	if c.adaptor != nil {
+		return [64]byte{}, errors.New("coordinator has an adaptor point, use RunAdaptor")
+	}
+	reveals, partials, err := c.exchange(ctx)
+	if err != nil {
+		return [64]byte{}, err
+	}
+	Rs, sis := payloads(reveals, partials)
+	signature, err := crypto.CombinePartialSignatures(c.key, Rs, sis, c.message)
+	if err != nil {
+		return [64]byte{}, c.blamePartial(err, reveals, partials)
+	}
+
+	m, err := crypto.NewSignatureMessage(c.id, signature)
+	if err != nil {
+		return [64]byte{}, err
+	}
+	if err := c.broadcast(ctx, m); err != nil {
+		return [64]byte{}, errors.Wrap(err, "publish signature")
+	}
+	return signature, nil
+}
+
+// RunAdaptor is Run for a coordinator made WithAdaptor. It broadcasts the
+// partial signatures, from which every signer assembles the pre-signature,
+// and returns the pre-signature.
+func (c *Coordinator) RunAdaptor(ctx context.Context) (*crypto.AdaptorSignature, error) {
+	if c.adaptor == nil {
+		return nil, errors.New("coordinator has no adaptor point, use Run")
+	}
+	reveals, partials, err := c.exchange(ctx)
+	if err != nil {
+		return nil, err
+	}
+	Rs, sis := payloads(reveals, partials)
+	pre, err := crypto.CombinePartialPreSignatures(c.key, Rs, c.adaptor, sis, c.message)
+	if err != nil {
+		return nil, c.blamePartial(err, reveals, partials)
+	}
+	if err := c.broadcast(ctx, partials...); err != nil {
+		return nil, errors.Wrap(err, "broadcast partial signatures")
+	}
+	return pre, nil
+}
+
+// exchange runs the commit and reveal rounds and collects the partial
+// signatures, which it does not broadcast
+func (c *Coordinator) exchange(ctx context.Context) (reveals, partials []*crypto.RoundMessage, err error) {
+	commitments, err := c.round(ctx, crypto.MessageCommitment, nil, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	Cs := make([][]byte, len(commitments))
+	for i, commitment := range commitments {
+		Cs[i] = commitment.Payload
+	}
+	reveals, err = c.round(ctx, crypto.MessageReveal, Cs, func(m *crypto.RoundMessage) error {
+		Ri, _ := m.Reveal()
+		commitment, _ := commitments[m.Signer].Commitment()
+		if _, err := crypto.VerifyCommitment(commitment, Ri); err != nil {
+			proof, _ := crypto.NewRevealBlameProof(c.key, c.message, c.adaptor, commitments[m.Signer], m)
+			return &SignerError{Signer: m.Signer, Err: err, Proof: proof}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	Rs, _ := payloads(reveals, nil)
+	partials, err = c.collect(ctx, crypto.MessagePartialSignature, Rs, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	return reveals, partials, nil
+}
+
+// payloads are the R_i of the reveals and the s_i of the partial signatures
+func payloads(reveals, partials []*crypto.RoundMessage) (Rs [][]byte, sis []*big.Int) {
+	for _, reveal := range reveals {
+		Ri, _ := reveal.Reveal()
+		Rs = append(Rs, Ri)
+	}
+	for _, partial := range partials {
+		si, _ := partial.PartialSignature()
+		sis = append(sis, si)
+	}
+	return Rs, sis
+}
+
+// blamePartial turns a *crypto.PartialSignatureError into a *SignerError with
+// a blame proof against the first bad signer, the error names all of them
+func (c *Coordinator) blamePartial(err error, reveals, partials []*crypto.RoundMessage) error {
+	var partialErr *crypto.PartialSignatureError
+	if !errors.As(err, &partialErr) {
+		return err
+	}
+	bad := partialErr.Signers[0]
+	proof, _ := crypto.NewPartialBlameProof(c.key, c.message, c.adaptor, reveals, partials[bad])
+	return &SignerError{Signer: bad, Err: err, Proof: proof}
+}
+
+// round collects the messages of round t, checks them and broadcasts all of them
+func (c *Coordinator) round(ctx context.Context, t crypto.MessageType, previous [][]byte, check func(*crypto.RoundMessage) error) ([]*crypto.RoundMessage, error) {
+	messages, err := c.collect(ctx, t, previous, check)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.broadcast(ctx, messages...); err != nil {
+		return nil, errors.Wrapf(err, "broadcast %s", t)
+	}
+	return messages, nil
+}
+
+// broadcast sends messages to every signer within the round timeout
+func (c *Coordinator) broadcast(ctx context.Context, messages ...*crypto.RoundMessage) error {
+	ctx, cancel := context.WithTimeout(ctx, c.roundTimeout)
+	defer cancel()
+	for _, m := range messages {
+		if err := c.transport.Send(ctx, m); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// collect returns the message of round t of every signer, indexed by signer,
+// previous are the payloads of the round before, which the messages of round
+// t are signed over, see crypto.RoundMessage.VerifySignature.
+// Anyone reaching the transport can send messages in the name of a signer and
+// replay its earlier messages, so only messages signed by their sender count
+// and everything else is dropped: a signer is only blamed for what it signed.
+// Running out of the round timeout is a *TimeoutError, the end of ctx is
+// returned as it is.
+func (c *Coordinator) collect(ctx context.Context, t crypto.MessageType, previous [][]byte, check func(*crypto.RoundMessage) error) ([]*crypto.RoundMessage, error) {
+	roundCtx, cancel := context.WithTimeout(ctx, c.roundTimeout)
+	defer cancel()
+	n := len(c.key.PubKeys)
+	messages := make([]*crypto.RoundMessage, n)
+	for received := 0; received < n; {
+		m, err := c.transport.Receive(roundCtx)
+		if errors.Is(err, context.DeadlineExceeded) && ctx.Err() == nil {
+			var missing []int
+			for i, m := range messages {
+				if m == nil {
+					missing = append(missing, i)
+				}
+			}
+			return nil, &TimeoutError{Round: t, Missing: missing}
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if m.SessionID != c.id || m.Signer < 0 || m.Signer >= n || m.Type != t {
+			continue
+		}
+		if m.VerifySignature(c.key, c.message, c.adaptor, previous) != nil {
+			continue
+		}
+		if first := messages[m.Signer]; first != nil {
+			if bytes.Equal(first.Payload, m.Payload) {
+				continue
+			}
+			return nil, &SignerError{Signer: m.Signer, Err: errors.Errorf("signed two different %s messages", t)}
+		}
+		if check != nil {
+			if err := check(m); err != nil {
+				var signerErr *SignerError
+				if errors.As(err, &signerErr) {
+					return nil, err
+				}
+				return nil, &SignerError{Signer: m.Signer, Err: err}
+			}
+		}
+		messages[m.Signer] = m
+		received++
+	}
+	return messages, nil
+}
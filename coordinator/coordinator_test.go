@@ -0,0 +1,459 @@
+package coordinator
+
+import (
+	"context"
+	"math/big"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/renne444/musig-go/crypto"
+)
+
+func newTestSessions(t *testing.T, n int, message []byte, opts ...crypto.SessionOption) []*crypto.Session {
+	var pubkeys [][]byte
+	var privKeys []*big.Int
+	for i := 0; i < n; i++ {
+		Px, Py, pk := crypto.GenerateKeyPair()
+		pubkeys = append(pubkeys, crypto.PointMarshal(Px, Py))
+		privKeys = append(privKeys, pk)
+	}
+	key, err := crypto.AggregateKeys(pubkeys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sessions := make([]*crypto.Session, n)
+	for _, pk := range privKeys {
+		session, err := crypto.NewSession(key, pk, message, opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sessions[session.Index()] = session
+	}
+	return sessions
+}
+
+type signerResult struct {
+	signature [64]byte
+	err       error
+}
+
+// runSigners starts RunSigner for every session whose transport is not nil
+func runSigners(ctx context.Context, transports []Transport, id crypto.SessionID, sessions []*crypto.Session) chan signerResult {
+	results := make(chan signerResult, len(sessions))
+	for i, session := range sessions {
+		if transports[i] == nil {
+			continue
+		}
+		go func(transport Transport, session *crypto.Session) {
+			signature, err := RunSigner(ctx, transport, id, session)
+			results <- signerResult{signature, err}
+		}(transports[i], session)
+	}
+	return results
+}
+
+// tamperTransport changes the payload of the outgoing messages of one type and
+// signs them again if session is set
+type tamperTransport struct {
+	Transport
+	t       crypto.MessageType
+	tamper  func([]byte) []byte
+	session *crypto.Session
+}
+
+func (tt *tamperTransport) Send(ctx context.Context, m *crypto.RoundMessage) error {
+	if m.Type == tt.t {
+		changed := *m
+		changed.Payload = tt.tamper(append([]byte{}, m.Payload...))
+		if tt.session != nil {
+			if err := tt.session.SignMessage(&changed); err != nil {
+				return err
+			}
+		}
+		m = &changed
+	}
+	return tt.Transport.Send(ctx, m)
+}
+
+func TestCoordinator(t *testing.T) {
+	message := []byte("msg for signing")
+	sessions := newTestSessions(t, 4, message)
+	key := sessions[0].AggregateKey()
+	id, err := crypto.NewSessionID()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	coordinatorTransport, signerTransports := NewMemoryTransports(len(sessions))
+	defer coordinatorTransport.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	results := runSigners(ctx, signerTransports, id, sessions)
+
+	c, err := New(coordinatorTransport, id, key, message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signature, err := c.Run(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := crypto.VerifyMsg(signature, message, key.X, key.Y); !ok {
+		t.Fatal(err)
+	}
+	for range sessions {
+		r := <-results
+		if r.err != nil {
+			t.Fatal(r.err)
+		}
+		if r.signature != signature {
+			t.Error("signer got another signature than the coordinator")
+		}
+	}
+}
+
+func TestCoordinatorAdaptor(t *testing.T) {
+	message := []byte("msg for signing")
+	_, _, secret := crypto.GenerateKeyPair()
+	T := crypto.ScalarBaseMult(secret).Bytes()
+	sessions := newTestSessions(t, 3, message, crypto.WithAdaptor(T))
+	key := sessions[0].AggregateKey()
+	id, _ := crypto.NewSessionID()
+
+	coordinatorTransport, signerTransports := NewMemoryTransports(len(sessions))
+	defer coordinatorTransport.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	results := make(chan error, len(sessions))
+	pres := make(chan *crypto.AdaptorSignature, len(sessions))
+	for i, session := range sessions {
+		go func(transport Transport, session *crypto.Session) {
+			pre, err := RunAdaptorSigner(ctx, transport, id, session)
+			pres <- pre
+			results <- err
+		}(signerTransports[i], session)
+	}
+
+	c, err := New(coordinatorTransport, id, key, message, WithAdaptor(T))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Run(ctx); err == nil {
+		t.Error("adaptor coordinator should not run for a plain signature")
+	}
+	pre, err := c.RunAdaptor(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := crypto.AdaptorVerify(key.X, key.Y, T, message, pre); !ok {
+		t.Fatal(err)
+	}
+	for range sessions {
+		if err := <-results; err != nil {
+			t.Fatal(err)
+		}
+		if other := <-pres; other.S.Cmp(pre.S) != 0 || string(other.R) != string(pre.R) {
+			t.Error("signer got another pre-signature than the coordinator")
+		}
+	}
+
+	if _, err := RunSigner(ctx, signerTransports[0], id, sessions[0]); err == nil {
+		t.Error("adaptor session should not run for a plain signature")
+	}
+}
+
+func TestCoordinatorBlame(t *testing.T) {
+	message := []byte("msg for signing")
+	G := crypto.ScalarBaseMult(big.NewInt(1)).Bytes()
+	addOne := func(b []byte) []byte {
+		si := new(big.Int).SetBytes(b)
+		si.Add(si, big.NewInt(1)).Mod(si, crypto.Curve.N)
+		return si.FillBytes(b)
+	}
+	for _, tc := range []struct {
+		t      crypto.MessageType
+		tamper func([]byte) []byte
+	}{
+		{crypto.MessageReveal, func([]byte) []byte { return G }},
+		{crypto.MessagePartialSignature, addOne},
+	} {
+		sessions := newTestSessions(t, 3, message)
+		key := sessions[0].AggregateKey()
+		id, _ := crypto.NewSessionID()
+		coordinatorTransport, signerTransports := NewMemoryTransports(len(sessions))
+		signerTransports[2] = &tamperTransport{Transport: signerTransports[2], t: tc.t, tamper: tc.tamper, session: sessions[2]}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		runSigners(ctx, signerTransports, id, sessions)
+		c, err := New(coordinatorTransport, id, key, message)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = c.Run(ctx)
+		cancel()
+
+		var signerErr *SignerError
+		if !errors.As(err, &signerErr) {
+			t.Errorf("%s: expected the tampering signer to be blamed, got %v", tc.t, err)
+			continue
+		}
+		if signerErr.Signer != 2 {
+			t.Errorf("%s: blamed signer %d, want 2", tc.t, signerErr.Signer)
+		}
+		var partialErr *crypto.PartialSignatureError
+		if errors.As(err, &partialErr) && !reflect.DeepEqual(partialErr.Signers, []int{2}) {
+			t.Errorf("%s: blamed signers %v, want [2]", tc.t, partialErr.Signers)
+		}
+		if signerErr.Proof == nil {
+			t.Errorf("%s: no blame proof", tc.t)
+			continue
+		}
+		if err := signerErr.Proof.Verify(); err != nil {
+			t.Errorf("%s: blame proof does not verify: %v", tc.t, err)
+		}
+		if signerErr.Proof.Accused != 2 {
+			t.Errorf("%s: proof accuses signer %d, want 2", tc.t, signerErr.Proof.Accused)
+		}
+	}
+}
+
+func TestCoordinatorUnsigned(t *testing.T) {
+	message := []byte("msg for signing")
+	sessions := newTestSessions(t, 3, message)
+	key := sessions[0].AggregateKey()
+	id, _ := crypto.NewSessionID()
+	coordinatorTransport, signerTransports := NewMemoryTransports(len(sessions))
+	// the partial signature of signer 2 is changed after it was signed
+	signerTransports[2] = &tamperTransport{Transport: signerTransports[2], t: crypto.MessagePartialSignature, tamper: func(b []byte) []byte {
+		b[len(b)-1] ^= 1
+		return b
+	}}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	runSigners(ctx, signerTransports, id, sessions)
+	c, err := New(coordinatorTransport, id, key, message, WithRoundTimeout(500*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = c.Run(ctx)
+	var timeoutErr *TimeoutError
+	if !errors.As(err, &timeoutErr) {
+		t.Fatalf("a signer was blamed for a message it did not sign: %v", err)
+	}
+	if timeoutErr.Round != crypto.MessagePartialSignature || !reflect.DeepEqual(timeoutErr.Missing, []int{2}) {
+		t.Errorf("timed out in %s on %v, want partial_signature on [2]", timeoutErr.Round, timeoutErr.Missing)
+	}
+}
+
+// noisyTransport sends and receives a copy of every message in the name of
+// the next signer, which does not verify, and a replay of the message before
+type noisyTransport struct {
+	Transport
+	n              int
+	sent, received *crypto.RoundMessage
+	pending        []*crypto.RoundMessage
+}
+
+func (nt *noisyTransport) noise(m, last *crypto.RoundMessage) []*crypto.RoundMessage {
+	forged := *m
+	forged.Signer = (m.Signer + 1) % nt.n
+	noise := []*crypto.RoundMessage{&forged, m}
+	if last != nil {
+		noise = append(noise, last)
+	}
+	return noise
+}
+
+func (nt *noisyTransport) Send(ctx context.Context, m *crypto.RoundMessage) error {
+	for _, m := range append([]*crypto.RoundMessage{m}, nt.noise(m, nt.sent)...) {
+		if err := nt.Transport.Send(ctx, m); err != nil {
+			return err
+		}
+	}
+	nt.sent = m
+	return nil
+}
+
+func (nt *noisyTransport) Receive(ctx context.Context) (*crypto.RoundMessage, error) {
+	if len(nt.pending) > 0 {
+		m := nt.pending[0]
+		nt.pending = nt.pending[1:]
+		return m, nil
+	}
+	m, err := nt.Transport.Receive(ctx)
+	if err != nil {
+		return nil, err
+	}
+	nt.pending = nt.noise(m, nt.received)
+	nt.received = m
+	return m, nil
+}
+
+func TestCoordinatorNoise(t *testing.T) {
+	message := []byte("msg for signing")
+	sessions := newTestSessions(t, 3, message)
+	key := sessions[0].AggregateKey()
+	id, _ := crypto.NewSessionID()
+	coordinatorTransport, signerTransports := NewMemoryTransports(len(sessions))
+	for i := range signerTransports {
+		signerTransports[i] = &noisyTransport{Transport: signerTransports[i], n: len(sessions)}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	results := runSigners(ctx, signerTransports, id, sessions)
+	c, err := New(coordinatorTransport, id, key, message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signature, err := c.Run(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for range sessions {
+		r := <-results
+		if r.err != nil {
+			t.Fatal(r.err)
+		}
+		if r.signature != signature {
+			t.Error("signer got another signature than the coordinator")
+		}
+	}
+}
+
+func TestCoordinatorTimeout(t *testing.T) {
+	message := []byte("msg for signing")
+	sessions := newTestSessions(t, 3, message)
+	key := sessions[0].AggregateKey()
+	id, _ := crypto.NewSessionID()
+	coordinatorTransport, signerTransports := NewMemoryTransports(len(sessions))
+	signerTransports[1] = nil
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	runSigners(ctx, signerTransports, id, sessions)
+	c, err := New(coordinatorTransport, id, key, message, WithRoundTimeout(100*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = c.Run(ctx)
+	var timeoutErr *TimeoutError
+	if !errors.As(err, &timeoutErr) {
+		t.Fatalf("expected TimeoutError, got %v", err)
+	}
+	if timeoutErr.Round != crypto.MessageCommitment || !reflect.DeepEqual(timeoutErr.Missing, []int{1}) {
+		t.Errorf("timed out in %s on %v, want commitment on [1]", timeoutErr.Round, timeoutErr.Missing)
+	}
+
+	// cancelling the context is not a timeout of the signers
+	cancel()
+	if _, err := c.Run(ctx); err != context.Canceled {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+
+	// neither is the deadline of the context
+	c, err = New(coordinatorTransport, id, key, message, WithRoundTimeout(time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel = context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if _, err := c.Run(ctx); err != context.DeadlineExceeded {
+		t.Errorf("expected context.DeadlineExceeded, got %v", err)
+	}
+}
+
+// wrapTransport wraps the errors of Receive
+type wrapTransport struct {
+	Transport
+}
+
+func (w wrapTransport) Receive(ctx context.Context) (*crypto.RoundMessage, error) {
+	m, err := w.Transport.Receive(ctx)
+	return m, errors.Wrap(err, "receive")
+}
+
+func TestCoordinatorTimeoutWrapped(t *testing.T) {
+	message := []byte("msg for signing")
+	sessions := newTestSessions(t, 2, message)
+	id, _ := crypto.NewSessionID()
+	coordinatorTransport, _ := NewMemoryTransports(len(sessions))
+	c, err := New(wrapTransport{coordinatorTransport}, id, sessions[0].AggregateKey(), message, WithRoundTimeout(50*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = c.Run(context.Background())
+	var timeoutErr *TimeoutError
+	if !errors.As(err, &timeoutErr) {
+		t.Fatalf("expected TimeoutError, got %v", err)
+	}
+	if !reflect.DeepEqual(timeoutErr.Missing, []int{0, 1}) {
+		t.Errorf("timed out on %v, want [0 1]", timeoutErr.Missing)
+	}
+}
+
+// recordTransport keeps the messages it sends
+type recordTransport struct {
+	Transport
+	sent []*crypto.RoundMessage
+}
+
+func (rt *recordTransport) Send(ctx context.Context, m *crypto.RoundMessage) error {
+	rt.sent = append(rt.sent, m)
+	return rt.Transport.Send(ctx, m)
+}
+
+func TestCoordinatorRetry(t *testing.T) {
+	message := []byte("msg for signing")
+	sessions := newTestSessions(t, 3, message)
+	key := sessions[0].AggregateKey()
+	id, _ := crypto.NewSessionID()
+
+	// the first run times out without signer 2
+	coordinatorTransport, signerTransports := NewMemoryTransports(len(sessions))
+	recorders := []*recordTransport{{Transport: signerTransports[0]}, {Transport: signerTransports[1]}}
+	signerTransports[0], signerTransports[1], signerTransports[2] = recorders[0], recorders[1], nil
+	ctx, cancel := context.WithCancel(context.Background())
+	results := runSigners(ctx, signerTransports, id, sessions)
+	c, err := New(coordinatorTransport, id, key, message, WithRoundTimeout(200*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var timeoutErr *TimeoutError
+	if _, err := c.Run(ctx); !errors.As(err, &timeoutErr) {
+		t.Fatalf("expected TimeoutError, got %v", err)
+	}
+	cancel()
+	<-results
+	<-results
+
+	// the retry under the same id sees the messages of the first run again
+	coordinatorTransport, signerTransports = NewMemoryTransports(len(sessions))
+	defer coordinatorTransport.Close()
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	for i, rt := range recorders {
+		for _, m := range rt.sent {
+			if err := signerTransports[i].Send(ctx, m); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	results = runSigners(ctx, signerTransports, id, sessions)
+	c, err = New(coordinatorTransport, id, key, message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signature, err := c.Run(ctx)
+	if err != nil {
+		t.Fatalf("retry under the same session id failed: %v", err)
+	}
+	for range sessions {
+		if r := <-results; r.err != nil || r.signature != signature {
+			t.Errorf("signer did not finish the retry: %v", r.err)
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package coordinator
+
+import (
+	"bytes"
+	"context"
+
+	"github.com/pkg/errors"
+	"github.com/renne444/musig-go/crypto"
+)
+
+// RunSigner takes session through the rounds of the coordinator at the other
+// end of transport and returns the final signature the coordinator publishes,
+// after checking it against the aggregate key. id must be fresh for every run,
+// session refuses to sign under another id than the first one it signed for.
+func RunSigner(ctx context.Context, transport Transport, id crypto.SessionID, session *crypto.Session) ([64]byte, error) {
+	if session.Adaptor() != nil {
+		return [64]byte{}, errors.New("session has an adaptor point, use RunAdaptorSigner")
+	}
+	if _, err := signRounds(ctx, transport, id, session); err != nil {
+		return [64]byte{}, err
+	}
+
+	for {
+		m, err := transport.Receive(ctx)
+		if err != nil {
+			return [64]byte{}, err
+		}
+		// replays and signatures that do not verify may come from anyone
+		if m.SessionID != id || m.Type != crypto.MessageSignature {
+			continue
+		}
+		signature, _ := m.Signature()
+		key := session.AggregateKey()
+		if ok, _ := crypto.VerifyMsg(signature, session.Message(), key.X, key.Y); ok {
+			return signature, nil
+		}
+	}
+}
+
+// RunAdaptorSigner is RunSigner for a session made crypto.WithAdaptor, it
+// returns the pre-signature assembled from the partial signatures the
+// coordinator broadcasts
+func RunAdaptorSigner(ctx context.Context, transport Transport, id crypto.SessionID, session *crypto.Session) (*crypto.AdaptorSignature, error) {
+	if session.Adaptor() == nil {
+		return nil, errors.New("session has no adaptor point, use RunSigner")
+	}
+	Rs, err := signRounds(ctx, transport, id, session)
+	if err != nil {
+		return nil, err
+	}
+	err = receiveRound(ctx, transport, id, session, crypto.MessagePartialSignature, Rs, func(m *crypto.RoundMessage) error {
+		si, _ := m.PartialSignature()
+		return session.AddPartialSignature(m.Signer, si)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return session.PreSignature()
+}
+
+// signRounds runs the commit and reveal rounds and sends the partial
+// signature, it returns the R_i of every signer
+func signRounds(ctx context.Context, transport Transport, id crypto.SessionID, session *crypto.Session) ([][]byte, error) {
+	own := session.Index()
+
+	commitment, err := crypto.NewCommitmentMessage(id, own, session.Commitment())
+	if err != nil {
+		return nil, err
+	}
+	if err := send(ctx, transport, session, commitment); err != nil {
+		return nil, errors.Wrap(err, "send commitment")
+	}
+	Cs := make([][]byte, len(session.AggregateKey().PubKeys))
+	Cs[own] = commitment.Payload
+	err = receiveRound(ctx, transport, id, session, crypto.MessageCommitment, nil, func(m *crypto.RoundMessage) error {
+		commitment, _ := m.Commitment()
+		if err := session.AddCommitment(m.Signer, commitment); err != nil {
+			return err
+		}
+		Cs[m.Signer] = m.Payload
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	Ri, err := session.Reveal()
+	if err != nil {
+		return nil, err
+	}
+	reveal, err := crypto.NewRevealMessage(id, own, Ri)
+	if err != nil {
+		return nil, err
+	}
+	if err := send(ctx, transport, session, reveal); err != nil {
+		return nil, errors.Wrap(err, "send reveal")
+	}
+	Rs := make([][]byte, len(session.AggregateKey().PubKeys))
+	Rs[own] = Ri
+	err = receiveRound(ctx, transport, id, session, crypto.MessageReveal, Cs, func(m *crypto.RoundMessage) error {
+		Ri, _ := m.Reveal()
+		if err := session.AddReveal(m.Signer, Ri); err != nil {
+			return err
+		}
+		Rs[m.Signer] = Ri
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	si, err := session.PartialSign()
+	if err != nil {
+		return nil, err
+	}
+	partial, err := crypto.NewPartialSignatureMessage(id, own, si)
+	if err != nil {
+		return nil, err
+	}
+	if err := send(ctx, transport, session, partial); err != nil {
+		return nil, errors.Wrap(err, "send partial signature")
+	}
+	return Rs, nil
+}
+
+// send signs m with the key of the session's signer before sending it
+func send(ctx context.Context, transport Transport, session *crypto.Session, m *crypto.RoundMessage) error {
+	if err := session.SignMessage(m); err != nil {
+		return err
+	}
+	return transport.Send(ctx, m)
+}
+
+// receiveRound hands the messages of the cosigners in round t to add until
+// the coordinator's broadcast of the round is complete, which includes the
+// echo of the signer's own message. As in Coordinator.collect only messages
+// signed by their sender count, previous are the payloads of the round before
+// that the messages are signed over.
+func receiveRound(ctx context.Context, transport Transport, id crypto.SessionID, session *crypto.Session, t crypto.MessageType, previous [][]byte, add func(*crypto.RoundMessage) error) error {
+	key := session.AggregateKey()
+	seen := make(map[int][]byte)
+	for len(seen) < len(key.PubKeys) {
+		m, err := transport.Receive(ctx)
+		if err != nil {
+			return err
+		}
+		if m.SessionID != id || m.Signer < 0 || m.Signer >= len(key.PubKeys) || m.Type != t {
+			continue
+		}
+		if m.VerifySignature(key, session.Message(), session.Adaptor(), previous) != nil {
+			continue
+		}
+		if payload, ok := seen[m.Signer]; ok {
+			if bytes.Equal(payload, m.Payload) {
+				continue
+			}
+			return &SignerError{Signer: m.Signer, Err: errors.Errorf("signed two different %s messages", t)}
+		}
+		if m.Signer != session.Index() {
+			if err := add(m); err != nil {
+				return &SignerError{Signer: m.Signer, Err: err}
+			}
+		}
+		seen[m.Signer] = m.Payload
+	}
+	return nil
+}
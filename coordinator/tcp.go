@@ -0,0 +1,256 @@
+package coordinator
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"io"
+	"net"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/renne444/musig-go/crypto"
+)
+
+// On TCP every message is a frame of a 4-byte big endian length followed by
+// the binary encoding of the RoundMessage. With a *tls.Config the connection
+// is wrapped in TLS, nil runs plain TCP.
+
+// maxFrameLen is far above the largest round message and stops a peer from
+// making us allocate arbitrary amounts of memory
+const maxFrameLen = 1 << 12
+
+func writeFrame(ctx context.Context, conn net.Conn, data []byte) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	deadline, _ := ctx.Deadline()
+	if err := conn.SetWriteDeadline(deadline); err != nil {
+		return err
+	}
+	frame := make([]byte, 4, 4+len(data))
+	binary.BigEndian.PutUint32(frame, uint32(len(data)))
+	_, err := conn.Write(append(frame, data...))
+	return err
+}
+
+func readFrame(r io.Reader) (*crypto.RoundMessage, error) {
+	var head [4]byte
+	if _, err := io.ReadFull(r, head[:]); err != nil {
+		return nil, err
+	}
+	n := binary.BigEndian.Uint32(head[:])
+	if n > maxFrameLen {
+		return nil, errors.Errorf("frame of %d bytes too long", n)
+	}
+	data := make([]byte, n)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, err
+	}
+	m := new(crypto.RoundMessage)
+	if err := m.UnmarshalBinary(data); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+type received struct {
+	m   *crypto.RoundMessage
+	err error
+}
+
+func receive(ctx context.Context, inbox <-chan received, closed <-chan struct{}) (*crypto.RoundMessage, error) {
+	select {
+	case r := <-inbox:
+		return r.m, r.err
+	case <-closed:
+		return nil, ErrClosed
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Server is the Transport of the coordinator, the signers connect to it with Dial
+type Server struct {
+	ln        net.Listener
+	inbox     chan received
+	closed    chan struct{}
+	closeOnce sync.Once
+
+	mu    sync.Mutex
+	conns []net.Conn
+}
+
+// Listen accepts signers on addr, e.g. "127.0.0.1:0"
+func Listen(addr string, config *tls.Config) (*Server, error) {
+	var ln net.Listener
+	var err error
+	if config != nil {
+		ln, err = tls.Listen("tcp", addr, config)
+	} else {
+		ln, err = net.Listen("tcp", addr)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "listen")
+	}
+	s := &Server{
+		ln:     ln,
+		inbox:  make(chan received, 64),
+		closed: make(chan struct{}),
+	}
+	go s.accept()
+	return s, nil
+}
+
+// Addr is the address the server listens on
+func (s *Server) Addr() net.Addr {
+	return s.ln.Addr()
+}
+
+func (s *Server) accept() {
+	for {
+		conn, err := s.ln.Accept()
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		s.conns = append(s.conns, conn)
+		s.mu.Unlock()
+		go s.read(conn)
+	}
+}
+
+// read drops a connection that sends a malformed frame, the round then times
+// out on the missing signer instead of one peer aborting the session
+func (s *Server) read(conn net.Conn) {
+	defer s.drop(conn)
+	for {
+		m, err := readFrame(conn)
+		if err != nil {
+			return
+		}
+		select {
+		case s.inbox <- received{m: m}:
+		case <-s.closed:
+			return
+		}
+	}
+}
+
+func (s *Server) drop(conn net.Conn) {
+	conn.Close()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, c := range s.conns {
+		if c == conn {
+			s.conns = append(s.conns[:i], s.conns[i+1:]...)
+			return
+		}
+	}
+}
+
+// Send writes m to every connected signer
+func (s *Server) Send(ctx context.Context, m *crypto.RoundMessage) error {
+	data, err := m.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	conns := append([]net.Conn{}, s.conns...)
+	s.mu.Unlock()
+	for _, conn := range conns {
+		if err := writeFrame(ctx, conn, data); err != nil {
+			return errors.Wrapf(err, "send to %s", conn.RemoteAddr())
+		}
+	}
+	return nil
+}
+
+// Receive returns the next message of any signer
+func (s *Server) Receive(ctx context.Context) (*crypto.RoundMessage, error) {
+	return receive(ctx, s.inbox, s.closed)
+}
+
+// Close stops listening and disconnects every signer
+func (s *Server) Close() error {
+	var err error
+	s.closeOnce.Do(func() {
+		close(s.closed)
+		err = s.ln.Close()
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		for _, conn := range s.conns {
+			conn.Close()
+		}
+	})
+	return err
+}
+
+// Client is the Transport of a signer connected to a Server
+type Client struct {
+	conn      net.Conn
+	inbox     chan received
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+// Dial connects to the coordinator at addr
+func Dial(ctx context.Context, addr string, config *tls.Config) (*Client, error) {
+	var conn net.Conn
+	var err error
+	if config != nil {
+		dialer := &tls.Dialer{Config: config}
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	} else {
+		var dialer net.Dialer
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "dial coordinator")
+	}
+	c := &Client{
+		conn:   conn,
+		inbox:  make(chan received, 64),
+		closed: make(chan struct{}),
+	}
+	go c.read()
+	return c, nil
+}
+
+func (c *Client) read() {
+	for {
+		m, err := readFrame(c.conn)
+		select {
+		case c.inbox <- received{m: m, err: err}:
+		case <-c.closed:
+			return
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// Send writes m to the coordinator
+func (c *Client) Send(ctx context.Context, m *crypto.RoundMessage) error {
+	data, err := m.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	return writeFrame(ctx, c.conn, data)
+}
+
+// Receive returns the next message of the coordinator
+func (c *Client) Receive(ctx context.Context) (*crypto.RoundMessage, error) {
+	return receive(ctx, c.inbox, c.closed)
+}
+
+// Close disconnects from the coordinator
+func (c *Client) Close() error {
+	var err error
+	c.closeOnce.Do(func() {
+		close(c.closed)
+		err = c.conn.Close()
+	})
+	return err
+}
@@ -0,0 +1,112 @@
+package coordinator
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/renne444/musig-go/crypto"
+)
+
+// newTestTLSConfigs returns a server config with a self-signed certificate for
+// 127.0.0.1 and a client config that trusts it
+func newTestTLSConfigs(t *testing.T) (*tls.Config, *tls.Config) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+	server := &tls.Config{Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}}}
+	client := &tls.Config{RootCAs: pool}
+	return server, client
+}
+
+func TestTCPTransport(t *testing.T) {
+	serverConfig, clientConfig := newTestTLSConfigs(t)
+	for _, tc := range []struct {
+		name           string
+		server, client *tls.Config
+	}{
+		{"tcp", nil, nil},
+		{"tls", serverConfig, clientConfig},
+	} {
+		message := []byte("msg for signing")
+		sessions := newTestSessions(t, 3, message)
+		key := sessions[0].AggregateKey()
+		id, _ := crypto.NewSessionID()
+
+		server, err := Listen("127.0.0.1:0", tc.server)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		transports := make([]Transport, len(sessions))
+		for i := range transports {
+			client, err := Dial(ctx, server.Addr().String(), tc.client)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer client.Close()
+			transports[i] = client
+		}
+		results := runSigners(ctx, transports, id, sessions)
+
+		c, err := New(server, id, key, message, WithRoundTimeout(5*time.Second))
+		if err != nil {
+			t.Fatal(err)
+		}
+		signature, err := c.Run(ctx)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		for range sessions {
+			if r := <-results; r.err != nil || r.signature != signature {
+				t.Errorf("%s: signer finished with %v", tc.name, r.err)
+			}
+		}
+		cancel()
+		server.Close()
+	}
+}
+
+func TestTCPTransportRejectsUntrustedServer(t *testing.T) {
+	serverConfig, _ := newTestTLSConfigs(t)
+	_, otherClient := newTestTLSConfigs(t)
+	server, err := Listen("127.0.0.1:0", serverConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if client, err := Dial(ctx, server.Addr().String(), otherClient); err == nil {
+		client.Close()
+		t.Error("dial should fail for a certificate of another authority")
+	}
+}
@@ -0,0 +1,87 @@
+package coordinator
+
+import (
+	"context"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/renne444/musig-go/crypto"
+)
+
+// ErrClosed is returned by a Transport after Close
+var ErrClosed = errors.New("transport closed")
+
+// Transport carries round messages between the coordinator and the signers.
+// On the coordinator side Send reaches every signer and Receive returns the
+// messages of all of them, on the side of a signer both go to the coordinator.
+type Transport interface {
+	Send(ctx context.Context, m *crypto.RoundMessage) error
+	// Receive blocks until a message arrives or ctx is done
+	Receive(ctx context.Context) (*crypto.RoundMessage, error)
+	Close() error
+}
+
+// memoryTransport passes messages through channels, every message goes
+// through its binary encoding so that no memory is shared between the ends
+type memoryTransport struct {
+	in        chan []byte
+	out       []chan []byte
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+// NewMemoryTransports connects a coordinator with n signers inside the process
+func NewMemoryTransports(n int) (Transport, []Transport) {
+	coordinator := &memoryTransport{
+		in:     make(chan []byte, 4*n),
+		closed: make(chan struct{}),
+	}
+	signers := make([]Transport, n)
+	for i := range signers {
+		signer := &memoryTransport{
+			in:     make(chan []byte, 4*n),
+			out:    []chan []byte{coordinator.in},
+			closed: make(chan struct{}),
+		}
+		coordinator.out = append(coordinator.out, signer.in)
+		signers[i] = signer
+	}
+	return coordinator, signers
+}
+
+func (t *memoryTransport) Send(ctx context.Context, m *crypto.RoundMessage) error {
+	data, err := m.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	for _, out := range t.out {
+		select {
+		case out <- data:
+		case <-t.closed:
+			return ErrClosed
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+func (t *memoryTransport) Receive(ctx context.Context) (*crypto.RoundMessage, error) {
+	select {
+	case data := <-t.in:
+		m := new(crypto.RoundMessage)
+		if err := m.UnmarshalBinary(data); err != nil {
+			return nil, err
+		}
+		return m, nil
+	case <-t.closed:
+		return nil, ErrClosed
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (t *memoryTransport) Close() error {
+	t.closeOnce.Do(func() { close(t.closed) })
+	return nil
+}
@@ -0,0 +1,151 @@
+package crypto
+
+import (
+	"math/big"
+
+	"github.com/pkg/errors"
+)
+
+// Adaptor signatures hide a secret t behind T = t*G. The pre-signature is
+// made for the nonce R + T but without t:
+//
+//	s' = g*r + H(P, x(R+T), m)*x    with g = -1 if R+T has no quadratic residue y
+//
+// so s = s' + g*t is a valid signature with Rx = x(R+T), and whoever sees both
+// s' and s learns t = g*(s - s').
+
+// AdaptorSignature is a pre-signature, R is the nonce point without T
+type AdaptorSignature struct {
+	// R is PointMarshal encoded
+	R []byte
+	S *big.Int
+}
+
+// adaptorNonce is R+T and whether its nonce has to be negated
+func adaptorNonce(R, T *Point) (*Point, bool, error) {
+	RT := R.Add(T)
+	if RT.IsInfinity() {
+		return nil, false, errors.New("adaptor nonce is the point at infinity")
+	}
+	return RT, !RT.hasSquareY(), nil
+}
+
+// AdaptorSign pre-signs message with pk for the PointMarshal encoded adaptor point T
+func AdaptorSign(pk *big.Int, T []byte, message []byte) (*AdaptorSignature, error) {
+	if err := ValidateSecretScalar(pk); err != nil {
+		return nil, errors.Wrap(err, "private key")
+	}
+	TP, err := pointUnmarshal(T)
+	if err != nil {
+		return nil, errors.Wrap(err, "adaptor point")
+	}
+	P := ScalarBaseMult(pk)
+	r, err := DeriveNonce(pk, message, append(P.Bytes(), T...))
+	if err != nil {
+		return nil, err
+	}
+
+	R := ScalarBaseMult(r)
+	RT, negate, err := adaptorNonce(R, TP)
+	if err != nil {
+		return nil, err
+	}
+	if negate {
+		r.Sub(Curve.N, r)
+	}
+	s := getHash(P, RT.X, message)
+	s.Mul(s, pk)
+	s.Add(s, r)
+	s.Mod(s, Curve.N)
+	return &AdaptorSignature{R: R.Bytes(), S: s}, nil
+}
+
+// AdaptorVerify checks s'*G == g*R + H(P, x(R+T), m)*P
+func AdaptorVerify(Px, Py *big.Int, T []byte, message []byte, sig *AdaptorSignature) (bool, error) {
+	P, err := NewPoint(Px, Py)
+	if err != nil {
+		return false, errors.Wrap(err, "public key")
+	}
+	return adaptorVerify(P, T, message, sig)
+}
+
+func adaptorVerify(P *Point, T []byte, message []byte, sig *AdaptorSignature) (bool, error) {
+	if sig == nil {
+		return false, errors.New("pre-signature is empty")
+	}
+	TP, err := pointUnmarshal(T)
+	if err != nil {
+		return false, errors.Wrap(err, "adaptor point")
+	}
+	R, err := pointUnmarshal(sig.R)
+	if err != nil {
+		return false, errors.Wrap(err, "pre-signature R")
+	}
+	if err := ValidateScalar(sig.S); err != nil {
+		return false, errors.Wrap(err, "pre-signature s")
+	}
+	RT, negate, err := adaptorNonce(R, TP)
+	if err != nil {
+		return false, err
+	}
+	if negate {
+		R = R.Neg()
+	}
+	e := getHash(P, RT.X, message)
+	if !ScalarBaseMult(sig.S).Equal(R.Add(P.ScalarMult(e))) {
+		return false, errors.New("pre-signature verification failed")
+	}
+	return true, nil
+}
+
+// Complete turns the pre-signature into the signature Rx||s with the adaptor secret t
+func (a *AdaptorSignature) Complete(t *big.Int) ([64]byte, error) {
+	if err := ValidateSecretScalar(t); err != nil {
+		return [64]byte{}, errors.Wrap(err, "adaptor secret")
+	}
+	R, err := pointUnmarshal(a.R)
+	if err != nil {
+		return [64]byte{}, errors.Wrap(err, "pre-signature R")
+	}
+	RT, negate, err := adaptorNonce(R, ScalarBaseMult(t))
+	if err != nil {
+		return [64]byte{}, err
+	}
+	gt := new(big.Int).Set(t)
+	if negate {
+		gt.Sub(Curve.N, gt)
+	}
+	s := gt.Add(gt, a.S)
+	s.Mod(s, Curve.N)
+	return signatureBytes(RT.X, s), nil
+}
+
+// Extract recovers the adaptor secret t of T from the completed signature
+func (a *AdaptorSignature) Extract(T []byte, signature [64]byte) (*big.Int, error) {
+	TP, err := pointUnmarshal(T)
+	if err != nil {
+		return nil, errors.Wrap(err, "adaptor point")
+	}
+	R, err := pointUnmarshal(a.R)
+	if err != nil {
+		return nil, errors.Wrap(err, "pre-signature R")
+	}
+	RT, negate, err := adaptorNonce(R, TP)
+	if err != nil {
+		return nil, err
+	}
+	if new(big.Int).SetBytes(signature[:32]).Cmp(RT.X) != 0 {
+		return nil, errors.New("signature does not belong to the pre-signature")
+	}
+
+	t := new(big.Int).SetBytes(signature[32:])
+	t.Sub(t, a.S)
+	t.Mod(t, Curve.N)
+	if negate {
+		t.Sub(Curve.N, t)
+	}
+	if !ScalarBaseMult(t).Equal(TP) {
+		return nil, errors.New("extracted secret does not match the adaptor point")
+	}
+	return t, nil
+}
@@ -0,0 +1,144 @@
+package crypto
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestAdaptorSignature(t *testing.T) {
+	Px, Py, pk := GenerateKeyPair()
+	Tx, Ty, secret := GenerateKeyPair()
+	T := PointMarshal(Tx, Ty)
+	message := []byte("msg for signing")
+
+	for i := 0; i < 8; i++ {
+		pre, err := AdaptorSign(pk, T, message)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ok, err := AdaptorVerify(Px, Py, T, message, pre); !ok {
+			t.Fatal(err)
+		}
+		if ok, _ := AdaptorVerify(Px, Py, T, []byte("other"), pre); ok {
+			t.Error("pre-signature verified for another message")
+		}
+
+		signature, err := pre.Complete(secret)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ok, err := VerifyMsg(signature, message, Px, Py); !ok {
+			t.Fatal(err)
+		}
+		extracted, err := pre.Extract(T, signature)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if extracted.Cmp(secret) != 0 {
+			t.Error("extracted secret differs from t")
+		}
+	}
+}
+
+func TestAdaptorRejects(t *testing.T) {
+	Px, Py, pk := GenerateKeyPair()
+	Tx, Ty, _ := GenerateKeyPair()
+	T := PointMarshal(Tx, Ty)
+	message := []byte("msg for signing")
+	pre, err := AdaptorSign(pk, T, message)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, _, wrong := GenerateKeyPair()
+	signature, err := pre.Complete(wrong)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, _ := VerifyMsg(signature, message, Px, Py); ok {
+		t.Error("completion with the wrong secret should not verify")
+	}
+
+	other, err := SignMsg(pk, message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := pre.Extract(T, other); err == nil {
+		t.Error("extraction from an unrelated signature should fail")
+	}
+	if _, err := AdaptorSign(pk, PointMarshal(Tx, new(big.Int).Add(Ty, big.NewInt(1))), message); err == nil {
+		t.Error("off-curve adaptor point should be rejected")
+	}
+}
+
+func newAdaptorSessions(t *testing.T, privKeys []*big.Int, key *AggregateKey, T, message []byte) []*Session {
+	sessions := make([]*Session, len(privKeys))
+	for _, pk := range privKeys {
+		session, err := NewSession(key, pk, message, WithAdaptor(T))
+		if err != nil {
+			t.Fatal(err)
+		}
+		sessions[session.Index()] = session
+	}
+	runCommitRound(t, sessions)
+	runRevealRound(t, sessions)
+	runPartialSignRound(t, sessions)
+	return sessions
+}
+
+// Alice knows t, a pre-signature on each chain lets Bob learn t once Alice claims hers
+func TestAdaptorSwap(t *testing.T) {
+	var privKeys []*big.Int
+	var pubKeys [][]byte
+	for i := 0; i < 2; i++ {
+		Px, Py, pk := GenerateKeyPair()
+		privKeys = append(privKeys, pk)
+		pubKeys = append(pubKeys, PointMarshal(Px, Py))
+	}
+	key, err := AggregateKeys(pubKeys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	Tx, Ty, secret := GenerateKeyPair()
+	T := PointMarshal(Tx, Ty)
+	toBob, toAlice := []byte("pay bob on chain one"), []byte("pay alice on chain two")
+
+	bobSessions := newAdaptorSessions(t, privKeys, key, T, toBob)
+	aliceSessions := newAdaptorSessions(t, privKeys, key, T, toAlice)
+	if _, err := bobSessions[0].Signature(); err == nil {
+		t.Error("adaptor session should not hand out a plain signature")
+	}
+	bobPre, err := bobSessions[0].PreSignature()
+	if err != nil {
+		t.Fatal(err)
+	}
+	alicePre, err := aliceSessions[1].PreSignature()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := AdaptorVerify(key.X, key.Y, T, toBob, bobPre); !ok {
+		t.Fatal(err)
+	}
+
+	// Alice claims her coins and reveals the signature
+	aliceSig, err := alicePre.Complete(secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := VerifyMsg(aliceSig, toAlice, key.X, key.Y); !ok {
+		t.Fatal(err)
+	}
+
+	// Bob learns t from it and claims his
+	extracted, err := alicePre.Extract(T, aliceSig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bobSig, err := bobPre.Complete(extracted)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := VerifyMsg(bobSig, toBob, key.X, key.Y); !ok {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,105 @@
+package crypto
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+
+	"github.com/pkg/errors"
+)
+
+// BatchItem is one (public key, message, signature) tuple for VerifyBatch
+type BatchItem struct {
+	Px, Py    *big.Int
+	Message   []byte
+	Signature [64]byte
+}
+
+// BatchError reports the items of a batch whose signature did not verify
+type BatchError struct {
+	Items []int
+}
+
+func (e *BatchError) Error() string {
+	return fmt.Sprintf("signature verification failed for batch items %v", e.Items)
+}
+
+// VerifyBatch checks all signatures at once with a random linear combination
+//
+//	sum(a_i*s_i)*G == sum(a_i*R_i) + sum(a_i*e_i*P_i)
+//
+// and falls back to Verify per item when the batch fails, the failed items are
+// reported in a *BatchError
+func VerifyBatch(items []BatchItem) (bool, error) {
+	if len(items) == 0 {
+		return true, nil
+	}
+
+	var points []*Point
+	var scalars []*big.Int
+	sumS := new(big.Int)
+	for i, item := range items {
+		P, R, s, e, err := batchTerms(item)
+		if err != nil {
+			return false, verifyEach(items)
+		}
+
+		// a_0 = 1, the other a_i are 128 random bits
+		a := big.NewInt(1)
+		if i > 0 {
+			a, err = rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+			if err != nil {
+				return false, errors.Wrap(err, "read batch randomness")
+			}
+		}
+
+		sumS.Add(sumS, new(big.Int).Mul(a, s))
+		ae := e.Mul(e, a)
+		ae.Mod(ae, Curve.N)
+		points = append(points, R, P)
+		scalars = append(scalars, a, ae)
+	}
+	if ScalarBaseMult(sumS).Equal(multiScalarMult(points, scalars)) {
+		return true, nil
+	}
+
+	if err := verifyEach(items); err != nil {
+		return false, err
+	}
+	// only possible if the batch equation is wrong, never accept silently
+	return false, errors.New("batch verification failed but every item verified")
+}
+
+func verifyEach(items []BatchItem) error {
+	var failed []int
+	for i, item := range items {
+		if _, _, _, _, err := batchTerms(item); err != nil {
+			failed = append(failed, i)
+		} else if ok, _ := VerifyMsg(item.Signature, item.Message, item.Px, item.Py); !ok {
+			failed = append(failed, i)
+		}
+	}
+	if failed == nil {
+		return nil
+	}
+	return &BatchError{Items: failed}
+}
+
+// R is the point of Rx with a quadratic residue y, the one Verify accepts
+func batchTerms(item BatchItem) (P, R *Point, s, e *big.Int, err error) {
+	P, err = NewPoint(item.Px, item.Py)
+	if err != nil {
+		return nil, nil, nil, nil, errors.Wrap(err, "public key")
+	}
+	Rx := new(big.Int).SetBytes(item.Signature[:32])
+	Ry, err := curveY(Rx)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	s = new(big.Int).SetBytes(item.Signature[32:])
+	if err := ValidateScalar(s); err != nil {
+		return nil, nil, nil, nil, errors.Wrap(err, "signature s")
+	}
+	e = getHash(P, Rx, item.Message)
+	return P, &Point{X: Rx, Y: Ry}, s, e, nil
+}
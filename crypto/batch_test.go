@@ -0,0 +1,88 @@
+package crypto
+
+import (
+	"fmt"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func newTestBatch(t testing.TB, n int) []BatchItem {
+	var items []BatchItem
+	for i := 0; i < n; i++ {
+		Px, Py, pk := GenerateKeyPair()
+		message := []byte(fmt.Sprintf("message %d", i))
+		signature, err := SignMsg(pk, message)
+		if err != nil {
+			t.Fatal(err)
+		}
+		items = append(items, BatchItem{Px: Px, Py: Py, Message: message, Signature: signature})
+	}
+	return items
+}
+
+func TestMultiScalarMult(t *testing.T) {
+	var points []*Point
+	var scalars []*big.Int
+	exp := Infinity()
+	for i := 0; i < 40; i++ {
+		Px, Py, _ := GenerateKeyPair()
+		_, _, k := GenerateKeyPair()
+		P := &Point{X: Px, Y: Py}
+		points, scalars = append(points, P), append(scalars, k)
+		exp = exp.Add(P.ScalarMult(k))
+	}
+	// P + (-P) and P + P inside the same bucket
+	points = append(points, points[0], points[0].Neg())
+	scalars = append(scalars, big.NewInt(5), big.NewInt(5))
+
+	if !multiScalarMult(points, scalars).Equal(exp) {
+		t.Error("multi-scalar multiplication differs from ScalarMult and Add")
+	}
+}
+
+func TestVerifyBatch(t *testing.T) {
+	items := newTestBatch(t, 20)
+	if ok, err := VerifyBatch(items); !ok {
+		t.Fatal(err)
+	}
+
+	items[3].Message = []byte("forged")
+	items[17].Signature[40] ^= 1
+	ok, err := VerifyBatch(items)
+	if ok {
+		t.Fatal("batch with forged signatures should fail")
+	}
+	var batchErr *BatchError
+	if !errors.As(err, &batchErr) {
+		t.Fatalf("expected BatchError, got %v", err)
+	}
+	if !reflect.DeepEqual(batchErr.Items, []int{3, 17}) {
+		t.Errorf("failed items %v, want [3 17]", batchErr.Items)
+	}
+}
+
+func BenchmarkVerifyBatch(b *testing.B) {
+	for _, n := range []int{16, 128, 1024} {
+		items := newTestBatch(b, n)
+
+		b.Run(fmt.Sprintf("Batch-%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if ok, err := VerifyBatch(items); !ok {
+					b.Fatal(err)
+				}
+			}
+		})
+		b.Run(fmt.Sprintf("Loop-%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				for _, item := range items {
+					if ok, err := VerifyMsg(item.Signature, item.Message, item.Px, item.Py); !ok {
+						b.Fatal(err)
+					}
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,172 @@
+package crypto
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"math/big"
+)
+
+// BIP-340 mode: tagged hashes, 32-byte x-only public keys and even-Y R and P,
+// so the signatures can be checked by any standard Bitcoin verifier.
+
+// sha256(sha256(tag)||sha256(tag)||msg)
+func taggedHash(tag string, msgs ...[]byte) [32]byte {
+	tagHash := sha256.Sum256([]byte(tag))
+	h := sha256.New()
+	h.Write(tagHash[:])
+	h.Write(tagHash[:])
+	for _, msg := range msgs {
+		h.Write(msg)
+	}
+	var ret [32]byte
+	copy(ret[:], h.Sum(nil))
+	return ret
+}
+
+// 32 bytes big endian, left padded
+func scalarBytes(i *big.Int) []byte {
+	ret := make([]byte, 32)
+	b := i.Bytes()
+	copy(ret[32-len(b):], b)
+	return ret
+}
+
+func hasEvenY(Py *big.Int) bool {
+	return Py.Bit(0) == 0
+}
+
+// liftX returns the point with x coordinate Px and an even y
+func liftX(Px *big.Int) (*Point, error) {
+	y, err := curveY(Px)
+	if err != nil {
+		return nil, err
+	}
+	if !hasEvenY(y) {
+		y.Sub(Curve.P, y)
+	}
+	return &Point{X: new(big.Int).Set(Px), Y: y}, nil
+}
+
+// curveY is the y = (x^3+7)^((P+1)/4) of Px, a quadratic residue itself
+func curveY(Px *big.Int) (*big.Int, error) {
+	if Px.Sign() < 0 || Px.Cmp(Curve.P) >= 0 {
+		return nil, errors.New("x coordinate exceeds the field size")
+	}
+	x := fieldFromBig(Px)
+	c := fieldSqr(&x)
+	c = fieldMul(&c, &x)
+	seven := fieldElement{7}
+	c = fieldAdd(&c, &seven)
+
+	y := fieldExp(&c, Curve.QPlus1Div4())
+	if fieldSqr(&y) != c {
+		return nil, errors.New("x coordinate is not on the curve")
+	}
+	return y.big(), nil
+}
+
+// XOnlyPubKey is the 32-byte BIP-340 public key of pk
+func XOnlyPubKey(pk *big.Int) [32]byte {
+	var ret [32]byte
+	copy(ret[:], scalarBytes(ScalarBaseMult(pk).X))
+	return ret
+}
+
+// SignBIP340 signs message with pk as in BIP-340, auxRand is 32 bytes of
+// fresh randomness, if it is nil it is read from crypto/rand
+func SignBIP340(pk *big.Int, message []byte, auxRand []byte) ([64]byte, error) {
+	if pk == nil || pk.Sign() <= 0 || pk.Cmp(Curve.N) >= 0 {
+		return [64]byte{}, errors.New("private key out of range")
+	}
+	if auxRand == nil {
+		auxRand = make([]byte, 32)
+		if _, err := rand.Read(auxRand); err != nil {
+			return [64]byte{}, err
+		}
+	}
+	if len(auxRand) != 32 {
+		return [64]byte{}, errors.New("auxiliary randomness must be 32 bytes")
+	}
+
+	P := ScalarBaseMult(pk)
+	d := new(big.Int).Set(pk)
+	if !hasEvenY(P.Y) {
+		d.Sub(Curve.N, d)
+	}
+	bPx := scalarBytes(P.X)
+
+	t := scalarBytes(d)
+	auxHash := taggedHash("BIP0340/aux", auxRand)
+	for i := range t {
+		t[i] ^= auxHash[i]
+	}
+	nonceHash := taggedHash("BIP0340/nonce", t, bPx, message)
+	k := new(big.Int).SetBytes(nonceHash[:])
+	k.Mod(k, Curve.N)
+	if k.Sign() == 0 {
+		return [64]byte{}, errors.New("nonce is zero")
+	}
+
+	R := ScalarBaseMult(k)
+	if !hasEvenY(R.Y) {
+		k.Sub(Curve.N, k)
+	}
+	bRx := scalarBytes(R.X)
+
+	e := getHashBIP340(bRx, bPx, message)
+	s := e.Mul(e, d)
+	s.Add(s, k)
+	s.Mod(s, Curve.N)
+
+	var sig [64]byte
+	copy(sig[:32], bRx)
+	copy(sig[32:], scalarBytes(s))
+
+	var pubKey [32]byte
+	copy(pubKey[:], bPx)
+	if ok, err := VerifyBIP340(pubKey[:], message, sig); !ok {
+		return [64]byte{}, err
+	}
+	return sig, nil
+}
+
+// int(hash_challenge(Rx||Px||m)) mod n
+func getHashBIP340(bRx, bPx, message []byte) *big.Int {
+	hashed := taggedHash("BIP0340/challenge", bRx, bPx, message)
+	e := new(big.Int).SetBytes(hashed[:])
+	return e.Mod(e, Curve.N)
+}
+
+// VerifyBIP340 checks a BIP-340 signature against a 32-byte x-only public key
+func VerifyBIP340(pubKey []byte, message []byte, signature [64]byte) (bool, error) {
+	if len(pubKey) != 32 {
+		return false, errors.New("signature verification failed, public key must be 32 bytes")
+	}
+	P, err := liftX(new(big.Int).SetBytes(pubKey))
+	if err != nil {
+		return false, err
+	}
+
+	Rx := new(big.Int).SetBytes(signature[:32])
+	if Rx.Cmp(Curve.P) >= 0 {
+		return false, errors.New("signature verification failed, Rx exceeds the field size")
+	}
+	s := new(big.Int).SetBytes(signature[32:])
+	if s.Cmp(Curve.N) >= 0 {
+		return false, errors.New("signature verification failed, s exceeds the curve order")
+	}
+
+	e := getHashBIP340(signature[:32], pubKey, message)
+
+	RCalc := ScalarBaseMult(s).Add(P.ScalarMult(e).Neg())
+
+	if RCalc.IsInfinity() {
+		return false, errors.New("signature verification failed, get zero Rx and Ry")
+	} else if !hasEvenY(RCalc.Y) {
+		return false, errors.New("signature verification failed, R has odd y")
+	} else if RCalc.X.Cmp(Rx) != 0 {
+		return false, errors.New("signature verification failed, Rx verification fail")
+	}
+	return true, nil
+}
@@ -0,0 +1,249 @@
+package crypto
+
+import (
+	"encoding/hex"
+	"math/big"
+
+	"github.com/pkg/errors"
+)
+
+// Signed round messages make a misbehaving cosigner identifiable to anyone.
+// A signer signs each message with the key it has in the aggregate key, over
+//
+//	H(X, m, T, prev, message encoding)
+//
+// where X is the aggregate key, m the signed message, T the adaptor point or
+// nothing and prev what the message follows: the sender's own commitment for
+// a reveal, the reveals of every signer for a partial signature and nothing
+// for a commitment. Binding X, m and T keeps a message from being replayed
+// into another signing, binding the commitment keeps a reveal from being
+// paired with the commitment of another run under the same SessionID, and
+// binding the reveals keeps a cosigner that shows different reveals to
+// different signers from framing an honest partial signature. A BlameProof collects signed messages of the accused that
+// contradict each other: a reveal that does not match the commitment or a
+// partial signature that does not verify against the reveals it was signed
+// over. Proofs cover untweaked keys, the signatures of other signings do not
+// verify in a proof.
+
+// roundMessageHash is the message the sender signs, adaptor is nil without
+// adaptor point and previous are the payloads of every signer in the round
+// before the one of m
+func roundMessageHash(m *RoundMessage, key *AggregateKey, message, adaptor []byte, previous [][]byte) []byte {
+	var prev []byte
+	switch m.Type {
+	case MessageReveal:
+		if m.Signer >= 0 && m.Signer < len(previous) {
+			prev = previous[m.Signer]
+		}
+	case MessagePartialSignature:
+		for _, Ri := range previous {
+			prev = append(prev, Ri...)
+		}
+	}
+	hashed := taggedHash("musig-go/round-message",
+		key.Bytes(),
+		lengthPrefixed(message),
+		lengthPrefixed(adaptor),
+		lengthPrefixed(prev),
+		m.signedBytes(WireVersionSigned))
+	return hashed[:]
+}
+
+// SignMessage turns m into a WireVersionSigned message signed with the key of
+// the session's signer, a reveal is signed over the session's commitment and
+// a partial signature over every reveal. The session is bound to the
+// SessionID of the first message it signs and refuses any other.
+func (s *Session) SignMessage(m *RoundMessage) error {
+	if m.Signer != s.index {
+		return errors.Errorf("message of signer %d in the session of signer %d", m.Signer, s.index)
+	}
+	if s.id != nil && *s.id != m.SessionID {
+		return errors.New("session already signs under another session id")
+	}
+	if m.Type == MessagePartialSignature && s.round < roundPartialSign {
+		return errors.New("can not sign a partial signature before all reveals are received")
+	}
+	var adaptor []byte
+	if s.adaptor != nil {
+		adaptor = s.adaptor.Bytes()
+	}
+	previous := s.reveals
+	if m.Type == MessageReveal {
+		previous = make([][]byte, len(s.commitments))
+		previous[s.index], _ = hex.DecodeString(s.commitments[s.index])
+	}
+	signature, err := SignMsg(s.privKey, roundMessageHash(m, s.key, s.message, adaptor, previous))
+	if err != nil {
+		return err
+	}
+	m.Version = WireVersionSigned
+	m.SenderSignature = signature[:]
+	if err := m.validate(); err != nil {
+		return err
+	}
+	id := m.SessionID
+	s.id = &id
+	return nil
+}
+
+// VerifySignature checks that m is signed by signer m.Signer of key for the
+// signing of message. adaptor is the adaptor point of a WithAdaptor session
+// or nil. previous are the payloads of every signer in the round before m:
+// the commitments for a reveal, the R_i a partial signature was made with
+// and nil for a commitment.
+func (m *RoundMessage) VerifySignature(key *AggregateKey, message, adaptor []byte, previous [][]byte) error {
+	if err := m.validate(); err != nil {
+		return err
+	}
+	if m.Version != WireVersionSigned {
+		return errors.New("message is not signed")
+	}
+	if m.Signer >= len(key.PubKeys) {
+		return errors.Errorf("signer index %d out of range", m.Signer)
+	}
+	P, err := pointUnmarshal(key.PubKeys[m.Signer])
+	if err != nil {
+		return err
+	}
+	Rx := new(big.Int).SetBytes(m.SenderSignature[:32])
+	s := new(big.Int).SetBytes(m.SenderSignature[32:])
+	if _, err := verify(P, Rx, s, roundMessageHash(m, key, message, adaptor, previous)); err != nil {
+		return errors.Wrapf(err, "signature of signer %d", m.Signer)
+	}
+	return nil
+}
+
+// BlameProof shows that signer Accused broke the protocol in the signing of
+// Message by the keys PubKeys, in key order, for the adaptor point Adaptor if
+// it is set. Evidence is either the commitment and reveal of the accused, or
+// the reveal of every signer and the partial signature of the accused, whose
+// signature covers the reveals.
+type BlameProof struct {
+	PubKeys  [][]byte        `json:"public_keys"`
+	Message  []byte          `json:"message"`
+	Adaptor  []byte          `json:"adaptor,omitempty"`
+	Accused  int             `json:"accused"`
+	Evidence []*RoundMessage `json:"evidence"`
+}
+
+// NewRevealBlameProof proves that the signed reveal does not match the signed
+// commitment of its sender, which the reveal is signed over. adaptor is nil
+// unless the session has one.
+func NewRevealBlameProof(key *AggregateKey, message, adaptor []byte, commitment, reveal *RoundMessage) (*BlameProof, error) {
+	return newBlameProof(key, message, adaptor, commitment.Signer, []*RoundMessage{commitment, reveal})
+}
+
+// NewPartialBlameProof proves that the signed partial signature does not verify
+// against the reveals it was signed over, reveals[i] is the reveal of signer i
+func NewPartialBlameProof(key *AggregateKey, message, adaptor []byte, reveals []*RoundMessage, partial *RoundMessage) (*BlameProof, error) {
+	evidence := append(append([]*RoundMessage{}, reveals...), partial)
+	return newBlameProof(key, message, adaptor, partial.Signer, evidence)
+}
+
+func newBlameProof(key *AggregateKey, message, adaptor []byte, accused int, evidence []*RoundMessage) (*BlameProof, error) {
+	if key.gacc != nil || key.tacc != nil {
+		return nil, errors.New("blame proofs do not cover tweaked keys")
+	}
+	p := &BlameProof{
+		PubKeys:  key.PubKeys,
+		Message:  message,
+		Adaptor:  adaptor,
+		Accused:  accused,
+		Evidence: evidence,
+	}
+	if err := p.Verify(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Verify returns nil if the evidence is signed by its senders and shows the misbehaviour of Accused
+func (p *BlameProof) Verify() error {
+	key, err := AggregateKeys(p.PubKeys, KeepKeyOrder())
+	if err != nil {
+		return err
+	}
+	if p.Accused < 0 || p.Accused >= len(key.PubKeys) || len(p.Evidence) == 0 {
+		return errors.New("malformed blame proof")
+	}
+	var T *Point
+	if p.Adaptor != nil {
+		if T, err = pointUnmarshal(p.Adaptor); err != nil {
+			return errors.Wrap(err, "adaptor point")
+		}
+	}
+
+	var commitment, partial *RoundMessage
+	reveals := make([]*RoundMessage, len(key.PubKeys))
+	for _, m := range p.Evidence {
+		if m == nil || m.validate() != nil || m.Signer >= len(key.PubKeys) {
+			return errors.New("malformed blame proof")
+		}
+		if m.SessionID != p.Evidence[0].SessionID {
+			return errors.New("evidence from different sessions")
+		}
+		switch {
+		case m.Type == MessageReveal && reveals[m.Signer] == nil:
+			reveals[m.Signer] = m
+		case m.Type == MessageCommitment && m.Signer == p.Accused && commitment == nil:
+			commitment = m
+		case m.Type == MessagePartialSignature && m.Signer == p.Accused && partial == nil:
+			partial = m
+		default:
+			return errors.Errorf("unexpected %s message of signer %d in the evidence", m.Type, m.Signer)
+		}
+	}
+
+	if commitment != nil {
+		reveal := reveals[p.Accused]
+		if reveal == nil || partial != nil {
+			return errors.New("malformed blame proof")
+		}
+		if err := commitment.VerifySignature(key, p.Message, p.Adaptor, nil); err != nil {
+			return err
+		}
+		// the accused signed the reveal over this commitment, not one of
+		// another run under the same SessionID
+		commitments := make([][]byte, len(key.PubKeys))
+		commitments[p.Accused] = commitment.Payload
+		if err := reveal.VerifySignature(key, p.Message, p.Adaptor, commitments); err != nil {
+			return err
+		}
+		c, _ := commitment.Commitment()
+		if ok, _ := VerifyCommitment(c, reveal.Payload); ok {
+			return errors.New("reveal matches the commitment")
+		}
+		return nil
+	}
+
+	if partial == nil {
+		return errors.New("malformed blame proof")
+	}
+	Rs := make([][]byte, len(reveals))
+	for i, reveal := range reveals {
+		if reveal == nil {
+			return errors.Errorf("reveal of signer %d missing", i)
+		}
+		Rs[i] = reveal.Payload
+	}
+	// the accused signed the reveals it made its partial signature with
+	if err := partial.VerifySignature(key, p.Message, p.Adaptor, Rs); err != nil {
+		return err
+	}
+	aggR, err := getAggregatePoints(Rs)
+	if err != nil {
+		return err
+	}
+	if T != nil {
+		aggR = aggR.Add(T)
+	}
+	if aggR.IsInfinity() {
+		return errors.New("aggregate nonce is the point at infinity")
+	}
+	si, _ := partial.PartialSignature()
+	e := getHash(&key.Point, aggR.X, p.Message)
+	if verifyPartialAt(key, p.Accused, Rs[p.Accused], !aggR.hasSquareY(), si, e) == nil {
+		return errors.New("partial signature is valid")
+	}
+	return nil
+}
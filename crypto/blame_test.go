@@ -0,0 +1,310 @@
+package crypto
+
+import (
+	"encoding/json"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+// previousPayloads returns the payloads a message of type t is signed over
+func previousPayloads(t MessageType, commitments, reveals []*RoundMessage) [][]byte {
+	var previous [][]byte
+	switch t {
+	case MessageReveal:
+		for _, m := range commitments {
+			previous = append(previous, m.Payload)
+		}
+	case MessagePartialSignature:
+		for _, m := range reveals {
+			previous = append(previous, m.Payload)
+		}
+	}
+	return previous
+}
+
+// newSignedRoundMessages returns the signed commitment, reveal and partial
+// signature messages of every session
+func newSignedRoundMessages(t *testing.T, sessions []*Session) (commitments, reveals, partials []*RoundMessage) {
+	id, err := NewSessionID()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return newSignedRoundMessagesWithID(t, id, sessions)
+}
+
+func newSignedRoundMessagesWithID(t *testing.T, id SessionID, sessions []*Session) (commitments, reveals, partials []*RoundMessage) {
+	runCommitRound(t, sessions)
+	runRevealRound(t, sessions)
+	for i, session := range sessions {
+		Ri, _ := session.Reveal()
+		si, err := session.PartialSign()
+		if err != nil {
+			t.Fatal(err)
+		}
+		commitment, _ := NewCommitmentMessage(id, i, session.Commitment())
+		reveal, _ := NewRevealMessage(id, i, Ri)
+		partial, _ := NewPartialSignatureMessage(id, i, si)
+		for _, m := range []*RoundMessage{commitment, reveal, partial} {
+			if err := session.SignMessage(m); err != nil {
+				t.Fatal(err)
+			}
+		}
+		commitments = append(commitments, commitment)
+		reveals = append(reveals, reveal)
+		partials = append(partials, partial)
+	}
+	return commitments, reveals, partials
+}
+
+func TestSignMessage(t *testing.T) {
+	message := []byte("msg for signing")
+	sessions := newTestSessions(t, 3, message)
+	key := sessions[0].AggregateKey()
+
+	id, _ := NewSessionID()
+	early, _ := NewPartialSignatureMessage(id, 0, big.NewInt(1))
+	if err := sessions[0].SignMessage(early); err == nil {
+		t.Error("partial signature signed before the reveals")
+	}
+	other, _ := NewCommitmentMessage(id, 1, sessions[1].Commitment())
+	if err := sessions[0].SignMessage(other); err == nil {
+		t.Error("message of another signer signed")
+	}
+
+	commitments, reveals, partials := newSignedRoundMessages(t, sessions)
+	Rs := previousPayloads(MessagePartialSignature, commitments, reveals)
+	for _, m := range append(reveals, partials...) {
+		previous := previousPayloads(m.Type, commitments, reveals)
+		data, err := m.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(data) != wireHeaderLen+m.Type.payloadLen()+64 {
+			t.Errorf("signed %s message of %d bytes", m.Type, len(data))
+		}
+		var decoded RoundMessage
+		if err := decoded.UnmarshalBinary(data); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(&decoded, m) {
+			t.Errorf("signed %s message changed after decoding", m.Type)
+		}
+		data, err = json.Marshal(m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		decoded = RoundMessage{}
+		if err := json.Unmarshal(data, &decoded); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(&decoded, m) {
+			t.Errorf("signed %s message changed after decoding JSON", m.Type)
+		}
+
+		if err := m.VerifySignature(key, message, nil, previous); err != nil {
+			t.Errorf("%s: %v", m.Type, err)
+		}
+		if err := m.VerifySignature(key, []byte("other msg"), nil, previous); err == nil {
+			t.Errorf("%s signature verified for another message", m.Type)
+		}
+		changed := *m
+		changed.Signer = (m.Signer + 1) % len(sessions)
+		if err := changed.VerifySignature(key, message, nil, previous); err == nil {
+			t.Errorf("%s signature verified for another signer", m.Type)
+		}
+	}
+
+	// the session stays with the id it signed for
+	again, _ := NewCommitmentMessage(commitments[0].SessionID, 0, sessions[0].Commitment())
+	if err := sessions[0].SignMessage(again); err != nil {
+		t.Error(err)
+	}
+	otherID, _ := NewSessionID()
+	moved, _ := NewCommitmentMessage(otherID, 0, sessions[0].Commitment())
+	if err := sessions[0].SignMessage(moved); err == nil {
+		t.Error("session signed under another session id")
+	}
+
+	// a reveal is signed together with the commitment of its sender
+	Cs := previousPayloads(MessageReveal, commitments, reveals)
+	Cs[2] = Cs[1]
+	if err := reveals[2].VerifySignature(key, message, nil, Cs); err == nil {
+		t.Error("reveal verified over another commitment")
+	}
+
+	// a partial signature is signed together with the reveals it was made with
+	Rs[0], Rs[1] = Rs[1], Rs[0]
+	if err := partials[2].VerifySignature(key, message, nil, Rs); err == nil {
+		t.Error("partial signature verified over other reveals")
+	}
+
+	unsigned, _ := NewRevealMessage(id, 0, Rs[0])
+	if err := unsigned.VerifySignature(key, message, nil, nil); err == nil {
+		t.Error("unsigned message verified")
+	}
+	data, _ := unsigned.MarshalBinary()
+	data = append(data, make([]byte, 64)...)
+	if err := new(RoundMessage).UnmarshalBinary(data); err != ErrTrailingData {
+		t.Errorf("unsigned message with a signature, got %v", err)
+	}
+}
+
+func TestBlameProof(t *testing.T) {
+	message := []byte("msg for signing")
+	sessions := newTestSessions(t, 3, message)
+	key := sessions[0].AggregateKey()
+	commitments, reveals, partials := newSignedRoundMessages(t, sessions)
+
+	if _, err := NewRevealBlameProof(key, message, nil, commitments[1], reveals[1]); err == nil {
+		t.Error("blame proof for a matching reveal")
+	}
+	if _, err := NewPartialBlameProof(key, message, nil, reveals, partials[1]); err == nil {
+		t.Error("blame proof for a valid partial signature")
+	}
+
+	badReveal := *reveals[1]
+	badReveal.Payload = ScalarBaseMult(big.NewInt(1)).Bytes()
+	if err := sessions[1].SignMessage(&badReveal); err != nil {
+		t.Fatal(err)
+	}
+	si, _ := partials[1].PartialSignature()
+	badPartial, _ := NewPartialSignatureMessage(partials[1].SessionID, 1, si.Add(si, big.NewInt(1)).Mod(si, Curve.N))
+	if err := sessions[1].SignMessage(badPartial); err != nil {
+		t.Fatal(err)
+	}
+
+	revealProof, err := NewRevealBlameProof(key, message, nil, commitments[1], &badReveal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	partialProof, err := NewPartialBlameProof(key, message, nil, reveals, badPartial)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range []*BlameProof{revealProof, partialProof} {
+		data, err := json.Marshal(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var decoded BlameProof
+		if err := json.Unmarshal(data, &decoded); err != nil {
+			t.Fatal(err)
+		}
+		if err := decoded.Verify(); err != nil {
+			t.Error(err)
+		}
+		if decoded.Accused != 1 {
+			t.Errorf("proof accuses signer %d, want 1", decoded.Accused)
+		}
+
+		decoded.Message = []byte("other msg")
+		if err := decoded.Verify(); err == nil {
+			t.Error("proof verified for another message")
+		}
+		decoded.Message = p.Message
+		decoded.Accused = 2
+		if err := decoded.Verify(); err == nil {
+			t.Error("proof verified against another signer")
+		}
+	}
+
+	// an unsigned reveal proves nothing against its sender
+	forged := badReveal
+	forged.Version, forged.SenderSignature = WireVersion, nil
+	if _, err := NewRevealBlameProof(key, message, nil, commitments[1], &forged); err == nil {
+		t.Error("blame proof from an unsigned reveal")
+	}
+
+	tweaked, err := key.Tweak(big.NewInt(7))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewPartialBlameProof(tweaked, message, nil, reveals, badPartial); err == nil {
+		t.Error("blame proof for a tweaked key")
+	}
+}
+
+func TestBlameProofMixedRuns(t *testing.T) {
+	message := []byte("msg for signing")
+	key, privKeys := newTestKeys(t, 3)
+	id, _ := NewSessionID()
+
+	// two runs of the same signing under one SessionID, every signer honest
+	first, _ := newTestStoredSessions(t, key, privKeys, message)
+	second, _ := newTestStoredSessions(t, key, privKeys, message)
+	commitments, _, _ := newSignedRoundMessagesWithID(t, id, first)
+	_, reveals, _ := newSignedRoundMessagesWithID(t, id, second)
+
+	if _, err := NewRevealBlameProof(key, message, nil, commitments[1], reveals[1]); err == nil {
+		t.Fatal("blame proof from the commitment and reveal of two runs")
+	}
+	p := &BlameProof{
+		PubKeys:  key.PubKeys,
+		Message:  message,
+		Accused:  1,
+		Evidence: []*RoundMessage{commitments[1], reveals[1]},
+	}
+	if err := p.Verify(); err == nil {
+		t.Error("blame proof from two runs verified")
+	}
+}
+
+func TestSignMessageAdaptor(t *testing.T) {
+	message := []byte("msg for signing")
+	key, privKeys := newTestKeys(t, 3)
+	_, _, secret := GenerateKeyPair()
+	T := ScalarBaseMult(secret).Bytes()
+	sessions, _ := newTestStoredSessions(t, key, privKeys, message, WithAdaptor(T))
+	if string(sessions[0].Adaptor()) != string(T) {
+		t.Error("session has another adaptor point")
+	}
+	commitments, reveals, partials := newSignedRoundMessages(t, sessions)
+	Rs := previousPayloads(MessagePartialSignature, commitments, reveals)
+	sis := make([]*big.Int, len(partials))
+	for i := range partials {
+		sis[i], _ = partials[i].PartialSignature()
+	}
+
+	for _, m := range append(reveals, partials...) {
+		previous := previousPayloads(m.Type, commitments, reveals)
+		if err := m.VerifySignature(key, message, T, previous); err != nil {
+			t.Errorf("%s: %v", m.Type, err)
+		}
+		if err := m.VerifySignature(key, message, nil, previous); err == nil {
+			t.Errorf("%s signature verified without the adaptor point", m.Type)
+		}
+	}
+
+	pre, err := CombinePartialPreSignatures(key, Rs, T, sis, message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := AdaptorVerify(key.X, key.Y, T, message, pre); !ok {
+		t.Fatal(err)
+	}
+
+	if _, err := NewPartialBlameProof(key, message, T, reveals, partials[1]); err == nil {
+		t.Error("blame proof for a valid partial signature")
+	}
+	si := new(big.Int).Add(sis[1], big.NewInt(1))
+	badPartial, _ := NewPartialSignatureMessage(partials[1].SessionID, 1, si.Mod(si, Curve.N))
+	if err := sessions[1].SignMessage(badPartial); err != nil {
+		t.Fatal(err)
+	}
+	sis[1] = si
+	var partialErr *PartialSignatureError
+	if _, err := CombinePartialPreSignatures(key, Rs, T, sis, message); !errors.As(err, &partialErr) {
+		t.Errorf("expected PartialSignatureError, got %v", err)
+	}
+	proof, err := NewPartialBlameProof(key, message, T, reveals, badPartial)
+	if err != nil {
+		t.Fatal(err)
+	}
+	proof.Adaptor = nil
+	if err := proof.Verify(); err == nil {
+		t.Error("proof verified without the adaptor point")
+	}
+}
@@ -0,0 +1,110 @@
+package crypto
+
+import (
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Unhardened BIP32 derivation on top of the aggregate key. The cosigners
+// share no secret chain code, so it is derived from the key list L and every
+// cosigner computes the same extended key. A child is the tweak
+//
+//	K_i = K + IL*G    with IL||c_i = HMAC-SHA512(c, K||i)
+//
+// and signing for K_i only needs the child's AggregateKey, which carries IL
+// in its accumulated tweak. Hardened children would need the private key of
+// K, which nobody has.
+
+// HardenedKeyStart is the first hardened child index, those can not be derived
+const HardenedKeyStart = 0x80000000
+
+// ErrInvalidChild is returned for the rare index whose IL is out of range or
+// gives infinity, BIP32 skips to the next index
+var ErrInvalidChild = errors.New("invalid child, use the next index")
+
+// ExtendedKey is an aggregate key with a chain code
+type ExtendedKey struct {
+	key       *AggregateKey
+	ChainCode [32]byte
+	// Depth is 0 for the aggregate key, ChildNumber the index of the last derivation
+	Depth       uint8
+	ChildNumber uint32
+}
+
+// NewExtendedKey returns the root extended key of key with the chain code H(L)
+func NewExtendedKey(key *AggregateKey) *ExtendedKey {
+	var L []byte
+	for _, point := range key.PubKeys {
+		L = append(L, point...)
+	}
+	return &ExtendedKey{
+		key:       key,
+		ChainCode: taggedHash("musig-go/chaincode", L),
+	}
+}
+
+// Key is the aggregate key to sign for, with the derivation tweaks applied
+func (k *ExtendedKey) Key() *AggregateKey {
+	return k.key
+}
+
+// Child derives the unhardened child i
+func (k *ExtendedKey) Child(i uint32) (*ExtendedKey, error) {
+	if i >= HardenedKeyStart {
+		return nil, errors.Errorf("hardened child %d can not be derived from an aggregate key", i)
+	}
+	if k.Depth == 255 {
+		return nil, errors.New("maximum derivation depth reached")
+	}
+
+	var index [4]byte
+	binary.BigEndian.PutUint32(index[:], i)
+	mac := hmac.New(sha512.New, k.ChainCode[:])
+	mac.Write(k.key.Bytes())
+	mac.Write(index[:])
+	I := mac.Sum(nil)
+
+	IL := new(big.Int).SetBytes(I[:32])
+	if IL.Cmp(Curve.N) >= 0 {
+		return nil, ErrInvalidChild
+	}
+	child, err := k.key.Tweak(IL)
+	if err != nil {
+		return nil, ErrInvalidChild
+	}
+
+	ret := &ExtendedKey{
+		key:         child,
+		Depth:       k.Depth + 1,
+		ChildNumber: i,
+	}
+	copy(ret.ChainCode[:], I[32:])
+	return ret, nil
+}
+
+// DerivePath follows a path like "m/0/7" or "0/7" from k, "m" is k itself.
+// Empty elements as in "m//0" or "0/" are rejected.
+func (k *ExtendedKey) DerivePath(path string) (*ExtendedKey, error) {
+	parts := strings.Split(path, "/")
+	if parts[0] == "m" {
+		parts = parts[1:]
+	}
+	ret := k
+	for _, part := range parts {
+		i, err := strconv.ParseUint(part, 10, 32)
+		if err != nil {
+			return nil, errors.Errorf("invalid path element %q", part)
+		}
+		ret, err = ret.Child(uint32(i))
+		if err != nil {
+			return nil, errors.Wrapf(err, "path element %s", part)
+		}
+	}
+	return ret, nil
+}
@@ -0,0 +1,94 @@
+package crypto
+
+import (
+	"crypto/hmac"
+	"crypto/sha512"
+	"math/big"
+	"testing"
+)
+
+func TestExtendedKeyChild(t *testing.T) {
+	sessions := newTestSessions(t, 3, nil)
+	key := sessions[0].AggregateKey()
+	root := NewExtendedKey(key)
+
+	if NewExtendedKey(key).ChainCode != root.ChainCode {
+		t.Error("chain code should be deterministic")
+	}
+
+	child, err := root.Child(7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mac := hmac.New(sha512.New, root.ChainCode[:])
+	mac.Write(key.Bytes())
+	mac.Write([]byte{0, 0, 0, 7})
+	I := mac.Sum(nil)
+	exp := key.Add(ScalarBaseMult(new(big.Int).SetBytes(I[:32])))
+	if !child.Key().Equal(exp) {
+		t.Error("child key is not K + IL*G")
+	}
+	if string(child.ChainCode[:]) != string(I[32:]) || child.Depth != 1 || child.ChildNumber != 7 {
+		t.Error("child chain code, depth or number wrong")
+	}
+
+	if _, err := root.Child(HardenedKeyStart); err == nil {
+		t.Error("hardened derivation should be rejected")
+	}
+}
+
+func TestExtendedKeyDerivePath(t *testing.T) {
+	sessions := newTestSessions(t, 3, nil)
+	root := NewExtendedKey(sessions[0].AggregateKey())
+
+	byPath, err := root.DerivePath("m/0/5/2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	step := root
+	for _, i := range []uint32{0, 5, 2} {
+		if step, err = step.Child(i); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if !byPath.Key().Equal(&step.Key().Point) || byPath.ChainCode != step.ChainCode {
+		t.Error("DerivePath differs from repeated Child")
+	}
+
+	if self, err := root.DerivePath("m"); err != nil || self != root {
+		t.Errorf("path m should be the key itself, got %v", err)
+	}
+	for _, path := range []string{"m/x", "m/0/-1", "m/2147483648", "", "/", "m/", "m//0", "0/", "/0"} {
+		if _, err := root.DerivePath(path); err == nil {
+			t.Errorf("path %s should be rejected", path)
+		}
+	}
+}
+
+func TestSessionDerivedKey(t *testing.T) {
+	message := []byte("msg for signing")
+	base := newTestSessions(t, 3, message)
+	child, err := NewExtendedKey(base[0].AggregateKey()).DerivePath("m/1/42")
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := child.Key()
+
+	sessions := make([]*Session, len(base))
+	for i, session := range base {
+		if sessions[i], err = NewSession(key, session.privKey, message); err != nil {
+			t.Fatal(err)
+		}
+	}
+	runCommitRound(t, sessions)
+	runRevealRound(t, sessions)
+	runPartialSignRound(t, sessions)
+
+	signature, err := sessions[2].Signature()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := VerifyMsg(signature, message, key.X, key.Y); !ok {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,444 @@
+package crypto
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"io"
+	"math/big"
+
+	"github.com/pkg/errors"
+)
+
+// Pedersen DKG with Feldman commitments, every participant deals a random
+// polynomial f_i of degree t-1 and the group secret is sum(f_i(0)):
+//
+//  1. commit: publish A_ik = a_ik*G and a proof of knowledge of a_i0
+//  2. share: send f_i(j) to participant j, encrypted to its identity key
+//  3. complain: j checks f_i(j)*G == sum(A_ik*j^k) and otherwise complains,
+//     i answers with f_i(j) in the clear and is disqualified if it is wrong
+//
+// The result is a FrostKeyShare over the contributions of the qualified
+// participants, nobody ever learns the group secret.
+
+// DKGCommitment is the round one message of participant ID
+type DKGCommitment struct {
+	ID int
+	// Commitments[k] = a_k*G, PointMarshal encoded
+	Commitments [][]byte
+	// R, S prove knowledge of a_0 as a Schnorr signature bound to ID
+	R []byte
+	S *big.Int
+}
+
+// DKGShare is f_From(To), encrypted to the identity key of To
+type DKGShare struct {
+	From, To   int
+	Ciphertext []byte
+}
+
+// DKGComplaint is broadcast by From when the share of Against did not verify
+type DKGComplaint struct {
+	From, Against int
+}
+
+// DKGAnswer is the share of From to To in the clear, in reply to a complaint of To
+type DKGAnswer struct {
+	From, To int
+	Share    *big.Int
+}
+
+// DKG is the state of one participant
+type DKG struct {
+	id        int
+	threshold int
+	identity  *big.Int
+	peers     []*Point
+	context   []byte
+
+	coefficients []*big.Int
+	commitments  [][]*Point
+	shares       []*big.Int
+	// complaints[i] holds the accusers of participant i without an answer yet
+	complaints   []map[int]bool
+	disqualified []bool
+}
+
+// NewDKG starts the key generation of participant id, 1 <= id <= len(peers).
+// identity is the long-term key of the participant and peers the PointMarshal
+// encoded identity public keys of every participant in id order, including
+// its own, every participant must be given the same list and threshold.
+func NewDKG(id, threshold int, identity *big.Int, peers [][]byte) (*DKG, error) {
+	n := len(peers)
+	if threshold < 1 || threshold > n {
+		return nil, errors.Errorf("threshold %d out of range for %d participants", threshold, n)
+	}
+	if id < 1 || id > n {
+		return nil, errors.Errorf("participant %d out of range", id)
+	}
+	if err := ValidateSecretScalar(identity); err != nil {
+		return nil, errors.Wrap(err, "identity key")
+	}
+
+	d := &DKG{
+		id:           id,
+		threshold:    threshold,
+		identity:     new(big.Int).Set(identity),
+		peers:        make([]*Point, n),
+		commitments:  make([][]*Point, n),
+		shares:       make([]*big.Int, n),
+		complaints:   make([]map[int]bool, n),
+		disqualified: make([]bool, n),
+	}
+	context := []byte{byte(threshold >> 8), byte(threshold)}
+	for i, peer := range peers {
+		P, err := pointUnmarshal(peer)
+		if err != nil {
+			return nil, errors.Wrapf(err, "identity key of participant %d", i+1)
+		}
+		d.peers[i] = P
+		d.complaints[i] = make(map[int]bool)
+		context = append(context, peer...)
+	}
+	if !ScalarBaseMult(identity).Equal(d.peers[id-1]) {
+		return nil, errors.New("identity key does not match the peer list")
+	}
+	hashed := taggedHash("musig-go/dkg/context", context)
+	d.context = hashed[:]
+
+	d.coefficients = make([]*big.Int, threshold)
+	d.commitments[id-1] = make([]*Point, threshold)
+	for k := range d.coefficients {
+		a, err := randomScalar()
+		if err != nil {
+			return nil, err
+		}
+		d.coefficients[k] = a
+		d.commitments[id-1][k] = ScalarBaseMult(a)
+	}
+	d.shares[id-1] = evalPolynomial(d.coefficients, int64(id))
+	return d, nil
+}
+
+// ID of the participant
+func (d *DKG) ID() int {
+	return d.id
+}
+
+// c = H(context, i, A_i0, R)
+func (d *DKG) getProofChallenge(id int, A0, R *Point) *big.Int {
+	hashed := taggedHash("musig-go/dkg/proof", d.context, frostID(id), A0.Bytes(), R.Bytes())
+	c := new(big.Int).SetBytes(hashed[:])
+	return c.Mod(c, Curve.N)
+}
+
+// Commitment is the round one message, broadcast to every participant
+func (d *DKG) Commitment() (*DKGCommitment, error) {
+	k, err := randomScalar()
+	if err != nil {
+		return nil, err
+	}
+	R := ScalarBaseMult(k)
+	c := d.getProofChallenge(d.id, d.commitments[d.id-1][0], R)
+	s := c.Mul(c, d.coefficients[0])
+	s.Add(s, k)
+	s.Mod(s, Curve.N)
+
+	ret := &DKGCommitment{ID: d.id, R: R.Bytes(), S: s}
+	for _, A := range d.commitments[d.id-1] {
+		ret.Commitments = append(ret.Commitments, A.Bytes())
+	}
+	return ret, nil
+}
+
+func (d *DKG) checkPeer(id int) error {
+	if id < 1 || id > len(d.peers) {
+		return errors.Errorf("participant %d out of range", id)
+	}
+	if id == d.id {
+		return errors.Errorf("participant %d is the owner", id)
+	}
+	return nil
+}
+
+// AddCommitment records the commitments of a peer after checking its proof of knowledge
+func (d *DKG) AddCommitment(c *DKGCommitment) error {
+	if c == nil {
+		return errors.New("commitment is empty")
+	}
+	if err := d.checkPeer(c.ID); err != nil {
+		return err
+	}
+	if d.commitments[c.ID-1] != nil {
+		return errors.Errorf("commitment of participant %d already received", c.ID)
+	}
+	if len(c.Commitments) != d.threshold {
+		return errors.Errorf("participant %d committed to %d coefficients, want %d", c.ID, len(c.Commitments), d.threshold)
+	}
+	commitments := make([]*Point, d.threshold)
+	for k, A := range c.Commitments {
+		P, err := pointUnmarshal(A)
+		if err != nil {
+			return errors.Wrapf(err, "commitment %d of participant %d", k, c.ID)
+		}
+		commitments[k] = P
+	}
+
+	R, err := pointUnmarshal(c.R)
+	if err != nil {
+		return errors.Wrapf(err, "proof of participant %d", c.ID)
+	}
+	if err := ValidateScalar(c.S); err != nil {
+		return errors.Wrapf(err, "proof of participant %d", c.ID)
+	}
+	e := d.getProofChallenge(c.ID, commitments[0], R)
+	if !ScalarBaseMult(c.S).Equal(R.Add(commitments[0].ScalarMult(e))) {
+		return errors.Errorf("proof of knowledge of participant %d failed", c.ID)
+	}
+	d.commitments[c.ID-1] = commitments
+	return nil
+}
+
+func (d *DKG) allCommitments() bool {
+	for _, c := range d.commitments {
+		if c == nil {
+			return false
+		}
+	}
+	return true
+}
+
+// Shares returns the encrypted share of every peer, once every commitment has arrived
+func (d *DKG) Shares() ([]*DKGShare, error) {
+	if !d.allCommitments() {
+		return nil, errors.New("can not share before all commitments are received")
+	}
+	var ret []*DKGShare
+	for i := range d.peers {
+		to := i + 1
+		if to == d.id {
+			continue
+		}
+		ciphertext, err := d.encryptShare(to, evalPolynomial(d.coefficients, int64(to)))
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, &DKGShare{From: d.id, To: to, Ciphertext: ciphertext})
+	}
+	return ret, nil
+}
+
+// AES-256-GCM with the key H(context, x(identity_from*identity_to), from, to)
+func (d *DKG) shareCipher(from, to int) (cipher.AEAD, error) {
+	peer := from
+	if peer == d.id {
+		peer = to
+	}
+	S := d.peers[peer-1].ScalarMult(d.identity)
+	key := taggedHash("musig-go/dkg/share", d.context, scalarBytes(S.X), frostID(from), frostID(to))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func (d *DKG) encryptShare(to int, share *big.Int) ([]byte, error) {
+	aead, err := d.shareCipher(d.id, to)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, errors.Wrap(err, "read share nonce")
+	}
+	return aead.Seal(nonce, nonce, scalarBytes(share), append(frostID(d.id), frostID(to)...)), nil
+}
+
+func (d *DKG) decryptShare(from int, ciphertext []byte) (*big.Int, error) {
+	aead, err := d.shareCipher(from, d.id)
+	if err != nil {
+		return nil, err
+	}
+	if len(ciphertext) < aead.NonceSize() {
+		return nil, errors.New("share ciphertext too short")
+	}
+	nonce, sealed := ciphertext[:aead.NonceSize()], ciphertext[aead.NonceSize():]
+	plain, err := aead.Open(nil, nonce, sealed, append(frostID(from), frostID(d.id)...))
+	if err != nil {
+		return nil, errors.Wrap(err, "decrypt share")
+	}
+	if len(plain) != 32 {
+		return nil, errors.New("share must be 32 bytes")
+	}
+	return new(big.Int).SetBytes(plain), nil
+}
+
+// share of participant from to participant to must satisfy
+// f_from(to)*G == sum(A_from,k * to^k)
+func (d *DKG) verifyShare(from, to int, share *big.Int) error {
+	if err := ValidateScalar(share); err != nil {
+		return err
+	}
+	if !ScalarBaseMult(share).Equal(evalCommitments(d.commitments[from-1], to)) {
+		return errors.Errorf("share of participant %d does not match its commitments", from)
+	}
+	return nil
+}
+
+// sum(A_k * x^k), the public counterpart of evalPolynomial
+func evalCommitments(commitments []*Point, x int) *Point {
+	ret := Infinity()
+	power := big.NewInt(1)
+	for _, A := range commitments {
+		ret = ret.Add(A.ScalarMult(power))
+		power.Mul(power, big.NewInt(int64(x)))
+		power.Mod(power, Curve.N)
+	}
+	return ret
+}
+
+// AddShare decrypts and checks a share sent to this participant. If it is
+// invalid the returned complaint has to be broadcast, the error then explains why.
+func (d *DKG) AddShare(s *DKGShare) (*DKGComplaint, error) {
+	if s == nil {
+		return nil, errors.New("share is empty")
+	}
+	if s.To != d.id {
+		return nil, errors.Errorf("share is addressed to participant %d", s.To)
+	}
+	if err := d.checkPeer(s.From); err != nil {
+		return nil, err
+	}
+	if !d.allCommitments() {
+		return nil, errors.New("shares are only accepted after all commitments")
+	}
+	if d.shares[s.From-1] != nil {
+		return nil, errors.Errorf("share of participant %d already received", s.From)
+	}
+
+	share, err := d.decryptShare(s.From, s.Ciphertext)
+	if err == nil {
+		err = d.verifyShare(s.From, d.id, share)
+	}
+	if err != nil {
+		complaint := &DKGComplaint{From: d.id, Against: s.From}
+		d.complaints[s.From-1][d.id] = true
+		return complaint, err
+	}
+	d.shares[s.From-1] = share
+	return nil, nil
+}
+
+// AddComplaint records a complaint broadcast by a peer, the accused has to answer it with Answer
+func (d *DKG) AddComplaint(c *DKGComplaint) error {
+	if c == nil {
+		return errors.New("complaint is empty")
+	}
+	if err := d.checkPeer(c.From); err != nil {
+		return err
+	}
+	if c.Against < 1 || c.Against > len(d.peers) || c.Against == c.From {
+		return errors.Errorf("complaint against invalid participant %d", c.Against)
+	}
+	d.complaints[c.Against-1][c.From] = true
+	return nil
+}
+
+// Answer reveals the share this participant sent to the author of a complaint against it
+func (d *DKG) Answer(c *DKGComplaint) (*DKGAnswer, error) {
+	if c == nil || c.Against != d.id {
+		return nil, errors.New("complaint is not against this participant")
+	}
+	if err := d.checkPeer(c.From); err != nil {
+		return nil, err
+	}
+	return &DKGAnswer{From: d.id, To: c.From, Share: evalPolynomial(d.coefficients, int64(c.From))}, nil
+}
+
+// AddAnswer checks a revealed share against the commitments of its dealer,
+// a dealer whose revealed share is wrong is disqualified
+func (d *DKG) AddAnswer(a *DKGAnswer) error {
+	if a == nil {
+		return errors.New("answer is empty")
+	}
+	if a.From < 1 || a.From > len(d.peers) || a.To < 1 || a.To > len(d.peers) {
+		return errors.New("answer of invalid participant")
+	}
+	if !d.complaints[a.From-1][a.To] {
+		return errors.Errorf("no open complaint of participant %d against %d", a.To, a.From)
+	}
+	delete(d.complaints[a.From-1], a.To)
+	if a.From == d.id {
+		return nil
+	}
+	if err := d.verifyShare(a.From, a.To, a.Share); err != nil {
+		d.disqualified[a.From-1] = true
+		return errors.Wrapf(err, "participant %d disqualified", a.From)
+	}
+	if a.To == d.id {
+		d.shares[a.From-1] = new(big.Int).Set(a.Share)
+	}
+	return nil
+}
+
+// Disqualify excludes a participant that did not answer a complaint in time
+func (d *DKG) Disqualify(id int) error {
+	if err := d.checkPeer(id); err != nil {
+		return err
+	}
+	d.disqualified[id-1] = true
+	d.complaints[id-1] = make(map[int]bool)
+	return nil
+}
+
+// Finalize sums the shares of the qualified participants into the key share of this participant
+func (d *DKG) Finalize() (*FrostKeyShare, error) {
+	n := len(d.peers)
+	var qualified []int
+	for i := 0; i < n; i++ {
+		if d.disqualified[i] {
+			continue
+		}
+		if len(d.complaints[i]) != 0 {
+			return nil, errors.Errorf("complaint against participant %d not answered", i+1)
+		}
+		if d.commitments[i] == nil || d.shares[i] == nil {
+			return nil, errors.Errorf("share of participant %d missing", i+1)
+		}
+		qualified = append(qualified, i)
+	}
+	if d.disqualified[d.id-1] {
+		return nil, errors.New("this participant was disqualified")
+	}
+	if len(qualified) < d.threshold {
+		return nil, errors.Errorf("only %d qualified participants for threshold %d", len(qualified), d.threshold)
+	}
+
+	group := &FrostGroup{
+		Point:              *Infinity(),
+		Threshold:          d.threshold,
+		VerificationShares: make([]*Point, n),
+	}
+	secret := new(big.Int)
+	for _, i := range qualified {
+		group.Point = *group.Add(d.commitments[i][0])
+		secret.Add(secret, d.shares[i])
+	}
+	secret.Mod(secret, Curve.N)
+	if group.IsInfinity() {
+		return nil, errors.New("group key is the point at infinity")
+	}
+
+	for j := 0; j < n; j++ {
+		Y := Infinity()
+		for _, i := range qualified {
+			Y = Y.Add(evalCommitments(d.commitments[i], j+1))
+		}
+		group.VerificationShares[j] = Y
+	}
+	if !ScalarBaseMult(secret).Equal(group.VerificationShares[d.id-1]) {
+		return nil, errors.New("secret share does not match the verification share")
+	}
+	return &FrostKeyShare{ID: d.id, Secret: secret, Group: group}, nil
+}
@@ -0,0 +1,198 @@
+package crypto
+
+import (
+	"math/big"
+	"testing"
+)
+
+func newTestDKGs(t *testing.T, threshold, n int) []*DKG {
+	var identities []*big.Int
+	var peers [][]byte
+	for i := 0; i < n; i++ {
+		Px, Py, identity := GenerateKeyPair()
+		identities = append(identities, identity)
+		peers = append(peers, PointMarshal(Px, Py))
+	}
+	var dkgs []*DKG
+	for i := 0; i < n; i++ {
+		d, err := NewDKG(i+1, threshold, identities[i], peers)
+		if err != nil {
+			t.Fatal(err)
+		}
+		dkgs = append(dkgs, d)
+	}
+
+	for _, d := range dkgs {
+		c, err := d.Commitment()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, other := range dkgs {
+			if other != d {
+				if err := other.AddCommitment(c); err != nil {
+					t.Fatal(err)
+				}
+			}
+		}
+	}
+	return dkgs
+}
+
+// deliver every share except those rejected by tamper, the complaints are returned
+func runDKGShareRound(t *testing.T, dkgs []*DKG, tamper func(*DKGShare) bool) []*DKGComplaint {
+	var complaints []*DKGComplaint
+	for _, d := range dkgs {
+		shares, err := d.Shares()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, share := range shares {
+			if tamper != nil && tamper(share) {
+				share.Ciphertext[len(share.Ciphertext)-1] ^= 1
+			}
+			complaint, err := dkgs[share.To-1].AddShare(share)
+			if complaint != nil {
+				complaints = append(complaints, complaint)
+			} else if err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	return complaints
+}
+
+func TestDKG(t *testing.T) {
+	dkgs := newTestDKGs(t, 3, 4)
+	if complaints := runDKGShareRound(t, dkgs, nil); complaints != nil {
+		t.Fatalf("unexpected complaints %v", complaints)
+	}
+
+	var keyShares []*FrostKeyShare
+	for _, d := range dkgs {
+		share, err := d.Finalize()
+		if err != nil {
+			t.Fatal(err)
+		}
+		keyShares = append(keyShares, share)
+	}
+	group := keyShares[0].Group
+	for _, share := range keyShares[1:] {
+		if !share.Group.Equal(&group.Point) {
+			t.Fatal("participants disagree on the group key")
+		}
+	}
+	if _, err := ParsePoint(group.Bytes()); err != nil {
+		t.Fatal(err)
+	}
+
+	message := []byte("msg for signing")
+	commitments, zs := frostSign(t, keyShares, []int{4, 1, 3}, message)
+	signature, err := group.Aggregate(commitments, zs, message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := VerifyMsg(signature, message, group.X, group.Y); !ok {
+		t.Error(err)
+	}
+}
+
+func TestDKGComplaint(t *testing.T) {
+	dkgs := newTestDKGs(t, 2, 4)
+	complaints := runDKGShareRound(t, dkgs, func(s *DKGShare) bool {
+		return s.From == 2 && s.To == 3
+	})
+	if len(complaints) != 1 || complaints[0].From != 3 || complaints[0].Against != 2 {
+		t.Fatalf("expected one complaint of 3 against 2, got %v", complaints)
+	}
+	for _, d := range dkgs {
+		if d.ID() != 3 {
+			if err := d.AddComplaint(complaints[0]); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if _, err := dkgs[0].Finalize(); err == nil {
+		t.Error("finalize should wait for the answer")
+	}
+
+	// an honest answer resolves the complaint
+	answer, err := dkgs[1].Answer(complaints[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, d := range dkgs {
+		if err := d.AddAnswer(answer); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, d := range dkgs {
+		if _, err := d.Finalize(); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestDKGDisqualify(t *testing.T) {
+	dkgs := newTestDKGs(t, 2, 4)
+	complaints := runDKGShareRound(t, dkgs, func(s *DKGShare) bool {
+		return s.From == 4 && s.To == 1
+	})
+	for _, d := range dkgs {
+		if d.ID() != 1 {
+			if err := d.AddComplaint(complaints[0]); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	// a wrong answer disqualifies the dealer
+	answer, err := dkgs[3].Answer(complaints[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	answer.Share.Add(answer.Share, big.NewInt(1))
+	var keyShares []*FrostKeyShare
+	for _, d := range dkgs[:3] {
+		if err := d.AddAnswer(answer); err == nil {
+			t.Fatal("wrong answer should disqualify the dealer")
+		}
+		share, err := d.Finalize()
+		if err != nil {
+			t.Fatal(err)
+		}
+		keyShares = append(keyShares, share)
+	}
+
+	group := keyShares[0].Group
+	for _, share := range keyShares[1:] {
+		if !share.Group.Equal(&group.Point) {
+			t.Fatal("qualified participants disagree on the group key")
+		}
+	}
+	message := []byte("msg for signing")
+	commitments, zs := frostSign(t, keyShares, []int{1, 3}, message)
+	signature, err := group.Aggregate(commitments, zs, message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := VerifyMsg(signature, message, group.X, group.Y); !ok {
+		t.Error(err)
+	}
+}
+
+func TestDKGRejectsBadProof(t *testing.T) {
+	dkgs := newTestDKGs(t, 2, 2)
+	c, err := dkgs[0].Commitment()
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.S.Add(c.S, big.NewInt(1))
+
+	fresh, err := NewDKG(2, 2, dkgs[1].identity, [][]byte{dkgs[0].peers[0].Bytes(), dkgs[1].peers[1].Bytes()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := fresh.AddCommitment(c); err == nil {
+		t.Error("commitment with a wrong proof of knowledge should be rejected")
+	}
+}
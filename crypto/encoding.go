@@ -0,0 +1,60 @@
+package crypto
+
+import (
+	"math/big"
+
+	"github.com/pkg/errors"
+)
+
+// SEC1 point encodings next to the raw 64-byte X||Y of PointMarshal
+const (
+	compressedEvenPrefix = 0x02
+	compressedOddPrefix  = 0x03
+	uncompressedPrefix   = 0x04
+)
+
+// PointMarshalCompressed is the 33-byte SEC1 encoding 0x02/0x03||X
+func PointMarshalCompressed(Px, Py *big.Int) []byte {
+	ret := make([]byte, 33)
+	ret[0] = compressedEvenPrefix
+	if !hasEvenY(Py) {
+		ret[0] = compressedOddPrefix
+	}
+	copy(ret[1:], scalarBytes(Px))
+	return ret
+}
+
+// PointMarshalUncompressed is the 65-byte SEC1 encoding 0x04||X||Y
+func PointMarshalUncompressed(Px, Py *big.Int) []byte {
+	return append([]byte{uncompressedPrefix}, PointMarshal(Px, Py)...)
+}
+
+// PointDecode reads a point in any of the 33-byte compressed, 65-byte
+// uncompressed or 64-byte PointMarshal encodings, off-curve points and the
+// point at infinity are rejected
+func PointDecode(src []byte) (Px, Py *big.Int, err error) {
+	switch {
+	case len(src) == 33 && (src[0] == compressedEvenPrefix || src[0] == compressedOddPrefix):
+		Px = new(big.Int).SetBytes(src[1:])
+		Py, err = curveY(Px)
+		if err != nil {
+			return nil, nil, err
+		}
+		if hasEvenY(Py) != (src[0] == compressedEvenPrefix) {
+			Py.Sub(Curve.P, Py)
+		}
+	case len(src) == 65 && src[0] == uncompressedPrefix:
+		Px = new(big.Int).SetBytes(src[1:33])
+		Py = new(big.Int).SetBytes(src[33:])
+	case len(src) == 64:
+		Px = new(big.Int).SetBytes(src[:32])
+		Py = new(big.Int).SetBytes(src[32:])
+	default:
+		return nil, nil, errors.Errorf("unknown point encoding of %d bytes", len(src))
+	}
+
+	if err := ValidatePoint(Px, Py); err != nil {
+		return nil, nil, err
+	}
+	return Px, Py, nil
+}
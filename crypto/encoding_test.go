@@ -0,0 +1,52 @@
+package crypto
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestPointEncodings(t *testing.T) {
+	for i := 0; i < 20; i++ {
+		Px, Py, _ := GenerateKeyPair()
+		for _, encoded := range [][]byte{
+			PointMarshal(Px, Py),
+			PointMarshalCompressed(Px, Py),
+			PointMarshalUncompressed(Px, Py),
+		} {
+			x, y, err := PointDecode(encoded)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if x.Cmp(Px) != 0 || y.Cmp(Py) != 0 {
+				t.Errorf("point changed after decoding %d bytes", len(encoded))
+			}
+		}
+	}
+}
+
+func TestPointDecodeRejects(t *testing.T) {
+	Px, Py, _ := GenerateKeyPair()
+
+	offCurve := PointMarshalUncompressed(Px, new(big.Int).Add(Py, big.NewInt(1)))
+	if _, _, err := PointDecode(offCurve); err == nil {
+		t.Error("off-curve point should be rejected")
+	}
+	if _, _, err := PointDecode(make([]byte, 64)); err == nil {
+		t.Error("point at infinity should be rejected")
+	}
+	if _, _, err := PointDecode([]byte{0x00}); err == nil {
+		t.Error("SEC1 infinity should be rejected")
+	}
+
+	compressed := PointMarshalCompressed(Px, Py)
+	compressed[0] = 0x05
+	if _, _, err := PointDecode(compressed); err == nil {
+		t.Error("unknown prefix should be rejected")
+	}
+
+	// x = 5 has no y on secp256k1
+	noY := append([]byte{0x02}, scalarBytes(big.NewInt(5))...)
+	if _, _, err := PointDecode(noY); err == nil {
+		t.Error("x without y should be rejected")
+	}
+}
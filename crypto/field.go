@@ -0,0 +1,149 @@
+package crypto
+
+import (
+	"math/big"
+	"math/bits"
+)
+
+// Arithmetic modulo the secp256k1 field prime P = 2^256 - 0x1000003D1 on four
+// 64-bit limbs, least significant first. It is only used where many point
+// operations run in a row, big.Int allocates and divides on every step.
+
+type fieldElement [4]uint64
+
+// 2^256 mod P
+const fieldReduceConst = 0x1000003D1
+
+var fieldPrime = fieldElement{0xFFFFFFFEFFFFFC2F, 0xFFFFFFFFFFFFFFFF, 0xFFFFFFFFFFFFFFFF, 0xFFFFFFFFFFFFFFFF}
+
+func fieldFromBig(x *big.Int) (r fieldElement) {
+	var b [32]byte
+	x.FillBytes(b[:])
+	for i := 0; i < 4; i++ {
+		for j := 0; j < 8; j++ {
+			r[i] |= uint64(b[31-8*i-j]) << (8 * uint(j))
+		}
+	}
+	return r
+}
+
+func (a *fieldElement) big() *big.Int {
+	var b [32]byte
+	for i := 0; i < 4; i++ {
+		for j := 0; j < 8; j++ {
+			b[31-8*i-j] = byte(a[i] >> (8 * uint(j)))
+		}
+	}
+	return new(big.Int).SetBytes(b[:])
+}
+
+func (a *fieldElement) isZero() bool {
+	return a[0]|a[1]|a[2]|a[3] == 0
+}
+
+// r - P if r >= P
+func (r *fieldElement) reduceOnce() {
+	var t fieldElement
+	var borrow uint64
+	t[0], borrow = bits.Sub64(r[0], fieldPrime[0], 0)
+	t[1], borrow = bits.Sub64(r[1], fieldPrime[1], borrow)
+	t[2], borrow = bits.Sub64(r[2], fieldPrime[2], borrow)
+	t[3], borrow = bits.Sub64(r[3], fieldPrime[3], borrow)
+	if borrow == 0 {
+		*r = t
+	}
+}
+
+// r += c*2^256 folded back in as c*0x1000003D1
+func (r *fieldElement) fold(c uint64) {
+	hi, lo := bits.Mul64(c, fieldReduceConst)
+	var carry uint64
+	r[0], carry = bits.Add64(r[0], lo, 0)
+	r[1], carry = bits.Add64(r[1], hi, carry)
+	r[2], carry = bits.Add64(r[2], 0, carry)
+	r[3], carry = bits.Add64(r[3], 0, carry)
+	if carry != 0 {
+		// the value wrapped around 2^256 and is now tiny, one more fold can not overflow
+		r[0], carry = bits.Add64(r[0], fieldReduceConst, 0)
+		r[1], carry = bits.Add64(r[1], 0, carry)
+		r[2], carry = bits.Add64(r[2], 0, carry)
+		r[3], _ = bits.Add64(r[3], 0, carry)
+	}
+	r.reduceOnce()
+}
+
+func fieldAdd(a, b *fieldElement) (r fieldElement) {
+	var carry uint64
+	r[0], carry = bits.Add64(a[0], b[0], 0)
+	r[1], carry = bits.Add64(a[1], b[1], carry)
+	r[2], carry = bits.Add64(a[2], b[2], carry)
+	r[3], carry = bits.Add64(a[3], b[3], carry)
+	r.fold(carry)
+	return r
+}
+
+func fieldSub(a, b *fieldElement) (r fieldElement) {
+	var borrow uint64
+	r[0], borrow = bits.Sub64(a[0], b[0], 0)
+	r[1], borrow = bits.Sub64(a[1], b[1], borrow)
+	r[2], borrow = bits.Sub64(a[2], b[2], borrow)
+	r[3], borrow = bits.Sub64(a[3], b[3], borrow)
+	if borrow != 0 {
+		// a - b + 2^256 - 0x1000003D1 = a - b + P
+		r[0], borrow = bits.Sub64(r[0], fieldReduceConst, 0)
+		r[1], borrow = bits.Sub64(r[1], 0, borrow)
+		r[2], borrow = bits.Sub64(r[2], 0, borrow)
+		r[3], _ = bits.Sub64(r[3], 0, borrow)
+	}
+	return r
+}
+
+func fieldMul(a, b *fieldElement) fieldElement {
+	var t [8]uint64
+	for i := 0; i < 4; i++ {
+		var carry uint64
+		for j := 0; j < 4; j++ {
+			hi, lo := bits.Mul64(a[i], b[j])
+			var c uint64
+			lo, c = bits.Add64(lo, t[i+j], 0)
+			hi += c
+			lo, c = bits.Add64(lo, carry, 0)
+			hi += c
+			t[i+j] = lo
+			carry = hi
+		}
+		t[i+4] = carry
+	}
+
+	// t = lo + hi*2^256 = lo + hi*0x1000003D1 mod P
+	var r fieldElement
+	var carry uint64
+	for i := 0; i < 4; i++ {
+		hi, lo := bits.Mul64(t[4+i], fieldReduceConst)
+		var c uint64
+		lo, c = bits.Add64(lo, t[i], 0)
+		hi += c
+		lo, c = bits.Add64(lo, carry, 0)
+		hi += c
+		r[i] = lo
+		carry = hi
+	}
+	r.fold(carry)
+	return r
+}
+
+func fieldSqr(a *fieldElement) fieldElement {
+	return fieldMul(a, a)
+}
+
+// a^e
+func fieldExp(a *fieldElement, e *big.Int) fieldElement {
+	r := fieldElement{1}
+	for i := e.BitLen() - 1; i >= 0; i-- {
+		r = fieldSqr(&r)
+		if e.Bit(i) == 1 {
+			r = fieldMul(&r, a)
+		}
+	}
+	return r
+}
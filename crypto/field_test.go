@@ -0,0 +1,40 @@
+package crypto
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestFieldArithmetic(t *testing.T) {
+	edges := []*big.Int{
+		new(big.Int),
+		big.NewInt(1),
+		new(big.Int).Sub(Curve.P, big.NewInt(1)),
+		new(big.Int).Sub(Curve.P, big.NewInt(0x1000003D1)),
+	}
+	var values []*big.Int
+	values = append(values, edges...)
+	for i := 0; i < 20; i++ {
+		x, _, _ := GenerateKeyPair()
+		values = append(values, x)
+	}
+
+	for _, a := range values {
+		for _, b := range values {
+			fa, fb := fieldFromBig(a), fieldFromBig(b)
+
+			sum := fieldAdd(&fa, &fb)
+			if sum.big().Cmp(new(big.Int).Mod(new(big.Int).Add(a, b), Curve.P)) != 0 {
+				t.Fatalf("%x + %x", a, b)
+			}
+			diff := fieldSub(&fa, &fb)
+			if diff.big().Cmp(new(big.Int).Mod(new(big.Int).Sub(a, b), Curve.P)) != 0 {
+				t.Fatalf("%x - %x", a, b)
+			}
+			prod := fieldMul(&fa, &fb)
+			if prod.big().Cmp(new(big.Int).Mod(new(big.Int).Mul(a, b), Curve.P)) != 0 {
+				t.Fatalf("%x * %x", a, b)
+			}
+		}
+	}
+}
@@ -0,0 +1,333 @@
+package crypto
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"math/big"
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+// FROST t-of-n: the group secret x is shared with a polynomial of degree t-1,
+// participant i holds x_i = f(i). Any t of them sign with
+//
+//	R = sum(D_i + rho_i*E_i)    z_i = d_i + rho_i*e_i + lambda_i*x_i*H(Y, Rx, m)
+//
+// where rho_i binds the nonce of i to the message and every commitment of the
+// signing set, and lambda_i is the Lagrange coefficient of i in the set.
+// The nonces are negated together when R has no quadratic residue y, so
+// sum(z_i) with Rx is a signature Verify accepts for the group key Y.
+
+// FrostGroup is the public part of a FROST key, the embedded Point is the group key Y
+type FrostGroup struct {
+	Point
+	Threshold int
+	// VerificationShares[i-1] is x_i*G of participant i
+	VerificationShares []*Point
+}
+
+// FrostKeyShare is the secret share x_i of participant ID, IDs start at 1
+type FrostKeyShare struct {
+	ID     int
+	Secret *big.Int
+	Group  *FrostGroup
+}
+
+// FrostCommitment is the public nonce pair of a participant for one signing
+type FrostCommitment struct {
+	ID int
+	// D = d*G and E = e*G, PointMarshal encoded
+	D, E []byte
+}
+
+// FrostNonce is the secret nonce pair (d, e) of a participant, it must only be used once
+type FrostNonce struct {
+	d, e       *big.Int
+	Commitment FrostCommitment
+}
+
+// FrostDealer splits a fresh random key into n shares of which any threshold sign,
+// the dealer sees the whole key and must be trusted to forget it
+func FrostDealer(threshold, n int) (*FrostGroup, []*FrostKeyShare, error) {
+	if threshold < 1 || threshold > n {
+		return nil, nil, errors.Errorf("threshold %d out of range for %d participants", threshold, n)
+	}
+
+	coefficients := make([]*big.Int, threshold)
+	for i := range coefficients {
+		a, err := randomScalar()
+		if err != nil {
+			return nil, nil, err
+		}
+		coefficients[i] = a
+	}
+
+	group := &FrostGroup{
+		Point:              *ScalarBaseMult(coefficients[0]),
+		Threshold:          threshold,
+		VerificationShares: make([]*Point, n),
+	}
+	shares := make([]*FrostKeyShare, n)
+	for i := range shares {
+		x := evalPolynomial(coefficients, int64(i+1))
+		if err := ValidateSecretScalar(x); err != nil {
+			return nil, nil, errors.Wrapf(err, "share %d", i+1)
+		}
+		group.VerificationShares[i] = ScalarBaseMult(x)
+		shares[i] = &FrostKeyShare{ID: i + 1, Secret: x, Group: group}
+	}
+	return group, shares, nil
+}
+
+// a random scalar in [1, N)
+func randomScalar() (*big.Int, error) {
+	for {
+		k, err := rand.Int(rand.Reader, Curve.N)
+		if err != nil {
+			return nil, errors.Wrap(err, "read randomness")
+		}
+		if k.Sign() != 0 {
+			return k, nil
+		}
+	}
+}
+
+// f(x) = sum(coefficients[j]*x^j) mod N
+func evalPolynomial(coefficients []*big.Int, x int64) *big.Int {
+	bx := big.NewInt(x)
+	ret := new(big.Int)
+	for j := len(coefficients) - 1; j >= 0; j-- {
+		ret.Mul(ret, bx)
+		ret.Add(ret, coefficients[j])
+		ret.Mod(ret, Curve.N)
+	}
+	return ret
+}
+
+// lagrangeCoefficient is prod(j/(j-id)) over the other ids j of the signing set
+func lagrangeCoefficient(id int, ids []int) *big.Int {
+	num, den := big.NewInt(1), big.NewInt(1)
+	for _, j := range ids {
+		if j == id {
+			continue
+		}
+		num.Mul(num, big.NewInt(int64(j)))
+		num.Mod(num, Curve.N)
+		den.Mul(den, big.NewInt(int64(j-id)))
+		den.Mod(den, Curve.N)
+	}
+	den.ModInverse(den, Curve.N)
+	return num.Mul(num, den).Mod(num, Curve.N)
+}
+
+// GenerateFrostNonce derives a fresh nonce pair with DeriveNonce
+func GenerateFrostNonce(share *FrostKeyShare, message []byte) (*FrostNonce, error) {
+	if share == nil || share.Group == nil {
+		return nil, errors.New("key share is empty")
+	}
+	d, err := deriveNonce(share.Secret, message, share.Group.Bytes(), 1)
+	if err != nil {
+		return nil, err
+	}
+	e, err := deriveNonce(share.Secret, message, share.Group.Bytes(), 2)
+	if err != nil {
+		return nil, err
+	}
+	return &FrostNonce{
+		d: d,
+		e: e,
+		Commitment: FrostCommitment{
+			ID: share.ID,
+			D:  ScalarBaseMult(d).Bytes(),
+			E:  ScalarBaseMult(e).Bytes(),
+		},
+	}, nil
+}
+
+// frostSigningSet is the checked view of the commitments of one signing
+type frostSigningSet struct {
+	ids  []int
+	rhos map[int]*big.Int
+	// R_i = D_i + rho_i*E_i by id, negated when R was
+	nonces map[int]*Point
+	negate bool
+	R      *Point
+	e      *big.Int
+}
+
+func (g *FrostGroup) signingSet(commitments []FrostCommitment, message []byte) (*frostSigningSet, error) {
+	if len(commitments) < g.Threshold {
+		return nil, errors.Errorf("need %d commitments, got %d", g.Threshold, len(commitments))
+	}
+	sorted := append([]FrostCommitment{}, commitments...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].ID < sorted[j].ID })
+
+	// B is the encoded list of every (i, D_i, E_i)
+	var B []byte
+	Ds := make([]*Point, len(sorted))
+	Es := make([]*Point, len(sorted))
+	for k, c := range sorted {
+		if c.ID < 1 || c.ID > len(g.VerificationShares) {
+			return nil, errors.Errorf("participant %d not in group", c.ID)
+		}
+		if k > 0 && sorted[k-1].ID == c.ID {
+			return nil, errors.Errorf("duplicate commitment of participant %d", c.ID)
+		}
+		var err error
+		if Ds[k], err = pointUnmarshal(c.D); err != nil {
+			return nil, errors.Wrapf(err, "commitment D of participant %d", c.ID)
+		}
+		if Es[k], err = pointUnmarshal(c.E); err != nil {
+			return nil, errors.Wrapf(err, "commitment E of participant %d", c.ID)
+		}
+		B = append(B, frostID(c.ID)...)
+		B = append(B, c.D...)
+		B = append(B, c.E...)
+	}
+
+	set := &frostSigningSet{
+		rhos:   make(map[int]*big.Int),
+		nonces: make(map[int]*Point),
+		R:      Infinity(),
+	}
+	for k, c := range sorted {
+		rho := getFrostBindingFactor(&g.Point, c.ID, message, B)
+		Ri := Ds[k].Add(Es[k].ScalarMult(rho))
+		set.ids = append(set.ids, c.ID)
+		set.rhos[c.ID] = rho
+		set.nonces[c.ID] = Ri
+		set.R = set.R.Add(Ri)
+	}
+	if set.R.IsInfinity() {
+		return nil, errors.New("group nonce is the point at infinity")
+	}
+	if !set.R.hasSquareY() {
+		set.negate = true
+		set.R = set.R.Neg()
+		for id, Ri := range set.nonces {
+			set.nonces[id] = Ri.Neg()
+		}
+	}
+	set.e = getHash(&g.Point, set.R.X, message)
+	return set, nil
+}
+
+func frostID(id int) []byte {
+	var ret [4]byte
+	binary.BigEndian.PutUint32(ret[:], uint32(id))
+	return ret[:]
+}
+
+// rho_i = H(Y, i, m, B)
+func getFrostBindingFactor(Y *Point, id int, message, B []byte) *big.Int {
+	hashed := taggedHash("musig-go/frost/binding", Y.Bytes(), frostID(id), lengthPrefixed(message), B)
+	rho := new(big.Int).SetBytes(hashed[:])
+	return rho.Mod(rho, Curve.N)
+}
+
+// FrostSign returns z_i of share over message for the signing set of commitments,
+// which must include the commitment of nonce, the nonce is consumed
+func FrostSign(share *FrostKeyShare, nonce *FrostNonce, commitments []FrostCommitment, message []byte) (*big.Int, error) {
+	if share == nil || share.Group == nil {
+		return nil, errors.New("key share is empty")
+	}
+	if nonce == nil || nonce.d == nil || nonce.e == nil {
+		return nil, errors.New("nonce is empty or already used")
+	}
+	if nonce.Commitment.ID != share.ID {
+		return nil, errors.New("nonce belongs to another participant")
+	}
+	own := false
+	for _, c := range commitments {
+		if c.ID == share.ID {
+			if string(c.D) != string(nonce.Commitment.D) || string(c.E) != string(nonce.Commitment.E) {
+				return nil, errors.New("commitment of the signer does not match its nonce")
+			}
+			own = true
+		}
+	}
+	if !own {
+		return nil, errors.New("signer is not part of the signing set")
+	}
+
+	set, err := share.Group.signingSet(commitments, message)
+	if err != nil {
+		return nil, err
+	}
+
+	k := new(big.Int).Mul(set.rhos[share.ID], nonce.e)
+	k.Add(k, nonce.d)
+	k.Mod(k, Curve.N)
+	if set.negate {
+		k.Sub(Curve.N, k)
+	}
+	nonce.d, nonce.e = nil, nil
+
+	z := lagrangeCoefficient(share.ID, set.ids)
+	z.Mul(z, share.Secret)
+	z.Mul(z, set.e)
+	z.Add(z, k)
+	return z.Mod(z, Curve.N), nil
+}
+
+// VerifyShare checks z_i*G == R_i + lambda_i*H(Y, Rx, m)*Y_i for participant id
+func (g *FrostGroup) VerifyShare(id int, zi *big.Int, commitments []FrostCommitment, message []byte) error {
+	set, err := g.signingSet(commitments, message)
+	if err != nil {
+		return err
+	}
+	return g.verifyShare(set, id, zi)
+}
+
+func (g *FrostGroup) verifyShare(set *frostSigningSet, id int, zi *big.Int) error {
+	Ri, ok := set.nonces[id]
+	if !ok {
+		return errors.Errorf("participant %d is not part of the signing set", id)
+	}
+	if err := ValidateScalar(zi); err != nil {
+		return errors.Wrap(err, "signature share")
+	}
+	le := lagrangeCoefficient(id, set.ids)
+	le.Mul(le, set.e)
+	if !ScalarBaseMult(zi).Equal(Ri.Add(g.VerificationShares[id-1].ScalarMult(le))) {
+		return errors.New("signature share verification failed")
+	}
+	return nil
+}
+
+// Aggregate checks every share and sums them into Rx||z, a signature for
+// the group key, shares[id] is z_i of participant id. A *PartialSignatureError
+// names the participants whose share did not verify.
+func (g *FrostGroup) Aggregate(commitments []FrostCommitment, shares map[int]*big.Int, message []byte) ([64]byte, error) {
+	set, err := g.signingSet(commitments, message)
+	if err != nil {
+		return [64]byte{}, err
+	}
+	if len(shares) != len(set.ids) {
+		return [64]byte{}, errors.New("need one signature share per commitment")
+	}
+
+	var bad []int
+	z := new(big.Int)
+	for _, id := range set.ids {
+		zi, ok := shares[id]
+		if !ok {
+			return [64]byte{}, errors.Errorf("signature share of participant %d missing", id)
+		}
+		if err := g.verifyShare(set, id, zi); err != nil {
+			bad = append(bad, id)
+			continue
+		}
+		z.Add(z, zi)
+	}
+	if bad != nil {
+		return [64]byte{}, &PartialSignatureError{Signers: bad}
+	}
+	z.Mod(z, Curve.N)
+
+	if _, err := verify(&g.Point, set.R.X, z, message); err != nil {
+		return [64]byte{}, errors.Wrap(err, "aggregate signature")
+	}
+	return signatureBytes(set.R.X, z), nil
+}
@@ -0,0 +1,136 @@
+package crypto
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func TestFrostDealer(t *testing.T) {
+	group, shares, err := FrostDealer(3, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// any 3 shares interpolate to the group secret
+	for _, ids := range [][]int{{1, 2, 3}, {2, 4, 5}, {1, 3, 5}} {
+		x := new(big.Int)
+		for _, id := range ids {
+			x.Add(x, new(big.Int).Mul(lagrangeCoefficient(id, ids), shares[id-1].Secret))
+		}
+		if !ScalarBaseMult(x).Equal(&group.Point) {
+			t.Errorf("shares %v do not interpolate to the group key", ids)
+		}
+	}
+	for i, share := range shares {
+		if !ScalarBaseMult(share.Secret).Equal(group.VerificationShares[i]) {
+			t.Errorf("verification share %d does not match", i+1)
+		}
+	}
+
+	if _, _, err := FrostDealer(4, 3); err == nil {
+		t.Error("threshold above n should be rejected")
+	}
+}
+
+func frostSign(t *testing.T, shares []*FrostKeyShare, ids []int, message []byte) ([]FrostCommitment, map[int]*big.Int) {
+	nonces := make(map[int]*FrostNonce)
+	var commitments []FrostCommitment
+	for _, id := range ids {
+		nonce, err := GenerateFrostNonce(shares[id-1], message)
+		if err != nil {
+			t.Fatal(err)
+		}
+		nonces[id] = nonce
+		commitments = append(commitments, nonce.Commitment)
+	}
+	zs := make(map[int]*big.Int)
+	for _, id := range ids {
+		z, err := FrostSign(shares[id-1], nonces[id], commitments, message)
+		if err != nil {
+			t.Fatal(err)
+		}
+		zs[id] = z
+	}
+	return commitments, zs
+}
+
+func TestFrostSign(t *testing.T) {
+	group, shares, err := FrostDealer(3, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	message := []byte("msg for signing")
+
+	for _, ids := range [][]int{{1, 2, 3}, {5, 3, 4}, {1, 2, 3, 4, 5}, {2, 5, 1, 4}} {
+		commitments, zs := frostSign(t, shares, ids, message)
+		for id, z := range zs {
+			if err := group.VerifyShare(id, z, commitments, message); err != nil {
+				t.Errorf("share of %d: %v", id, err)
+			}
+		}
+		signature, err := group.Aggregate(commitments, zs, message)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ok, err := VerifyMsg(signature, message, group.X, group.Y); !ok {
+			t.Errorf("signers %v: %v", ids, err)
+		}
+	}
+}
+
+func TestFrostBadShare(t *testing.T) {
+	group, shares, err := FrostDealer(2, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	message := []byte("msg for signing")
+	commitments, zs := frostSign(t, shares, []int{1, 3}, message)
+	zs[3] = new(big.Int).Add(zs[3], big.NewInt(1))
+
+	_, err = group.Aggregate(commitments, zs, message)
+	var partialErr *PartialSignatureError
+	if !errors.As(err, &partialErr) {
+		t.Fatalf("expected PartialSignatureError, got %v", err)
+	}
+	if !reflect.DeepEqual(partialErr.Signers, []int{3}) {
+		t.Errorf("blamed %v, want [3]", partialErr.Signers)
+	}
+}
+
+func TestFrostSignRejects(t *testing.T) {
+	_, shares, err := FrostDealer(3, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	message := []byte("msg for signing")
+
+	var nonces []*FrostNonce
+	var commitments []FrostCommitment
+	for _, share := range shares[:3] {
+		nonce, err := GenerateFrostNonce(share, message)
+		if err != nil {
+			t.Fatal(err)
+		}
+		nonces = append(nonces, nonce)
+		commitments = append(commitments, nonce.Commitment)
+	}
+
+	if _, err := FrostSign(shares[0], nonces[0], commitments[:2], message); err == nil {
+		t.Error("signing set below the threshold should be rejected")
+	}
+	if _, err := FrostSign(shares[3], nonces[0], commitments, message); err == nil {
+		t.Error("nonce of another participant should be rejected")
+	}
+	if _, err := FrostSign(shares[0], nonces[0], append(commitments, commitments[1]), message); err == nil {
+		t.Error("duplicate commitment should be rejected")
+	}
+	if _, err := FrostSign(shares[0], nonces[0], commitments, message); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := FrostSign(shares[0], nonces[0], commitments, message); err == nil {
+		t.Error("nonce should only be usable once")
+	}
+}
@@ -0,0 +1,154 @@
+package crypto
+
+import (
+	"math/big"
+
+	"github.com/pkg/errors"
+)
+
+// Half-aggregation keeps the Rx of every signature and folds the s values into
+//
+//	s = sum(z_i*s_i)    z_0 = 1, z_i = H(h_i)
+//	h_i = H(h_{i-1}, Rx_i, P_i, m_i)
+//
+// which verifies as s*G == sum(z_i*R_i + z_i*e_i*P_i). z_i only depends on the
+// items up to i, so more signatures can be appended to an aggregate later.
+// n signatures take 32*(n+1) bytes instead of 64*n, verification costs one
+// multi-scalar multiplication like VerifyBatch, but a bad signature can no
+// longer be told apart from the others.
+
+// HalfAggItem is the public key and message of one half-aggregated signature
+type HalfAggItem struct {
+	Px, Py  *big.Int
+	Message []byte
+}
+
+// HalfAggSignature is Rx of every signature in order and the aggregated s
+type HalfAggSignature struct {
+	Rx []*big.Int
+	S  *big.Int
+}
+
+// Bytes is Rx_0||...||Rx_n-1||s
+func (a *HalfAggSignature) Bytes() []byte {
+	var ret []byte
+	for _, Rx := range a.Rx {
+		ret = append(ret, scalarBytes(Rx)...)
+	}
+	return append(ret, scalarBytes(a.S)...)
+}
+
+// ParseHalfAggSignature decodes the output of Bytes
+func ParseHalfAggSignature(b []byte) (*HalfAggSignature, error) {
+	if len(b) == 0 || len(b)%32 != 0 {
+		return nil, errors.Errorf("half-aggregate signature of %d bytes", len(b))
+	}
+	n := len(b)/32 - 1
+	ret := &HalfAggSignature{Rx: make([]*big.Int, n)}
+	for i := range ret.Rx {
+		ret.Rx[i] = new(big.Int).SetBytes(b[32*i : 32*(i+1)])
+		if err := ValidateFieldElement(ret.Rx[i]); err != nil {
+			return nil, errors.Wrapf(err, "Rx %d", i)
+		}
+	}
+	ret.S = new(big.Int).SetBytes(b[32*n:])
+	if err := ValidateScalar(ret.S); err != nil {
+		return nil, errors.Wrap(err, "s")
+	}
+	return ret, nil
+}
+
+// halfAggChain walks the hash chain h_i
+type halfAggChain struct {
+	h []byte
+}
+
+// next returns z_i for the item i following the ones seen so far
+func (c *halfAggChain) next(P *Point, Rx *big.Int, message []byte) *big.Int {
+	first := c.h == nil
+	hashed := taggedHash("musig-go/halfagg", c.h, scalarBytes(Rx), P.Bytes(), lengthPrefixed(message))
+	c.h = hashed[:]
+	if first {
+		return big.NewInt(1)
+	}
+	z := new(big.Int).SetBytes(hashed[:])
+	return z.Mod(z, Curve.N)
+}
+
+// HalfAggregate combines signatures made by Sign, they are not verified
+func HalfAggregate(items []BatchItem) (*HalfAggSignature, error) {
+	return IncrementalHalfAggregate(&HalfAggSignature{S: new(big.Int)}, nil, items)
+}
+
+// IncrementalHalfAggregate appends the signatures of items to agg, prior are the
+// items agg was made for and in the same order
+func IncrementalHalfAggregate(agg *HalfAggSignature, prior []HalfAggItem, items []BatchItem) (*HalfAggSignature, error) {
+	if agg == nil || agg.S == nil {
+		return nil, errors.New("half-aggregate signature is empty")
+	}
+	if len(prior) != len(agg.Rx) {
+		return nil, errors.Errorf("half-aggregate signature has %d items, got %d", len(agg.Rx), len(prior))
+	}
+	var chain halfAggChain
+	for i, item := range prior {
+		P, err := NewPoint(item.Px, item.Py)
+		if err != nil {
+			return nil, errors.Wrapf(err, "public key of item %d", i)
+		}
+		chain.next(P, agg.Rx[i], item.Message)
+	}
+
+	ret := &HalfAggSignature{
+		Rx: append([]*big.Int{}, agg.Rx...),
+		S:  new(big.Int).Set(agg.S),
+	}
+	for i, item := range items {
+		P, R, s, _, err := batchTerms(item)
+		if err != nil {
+			return nil, errors.Wrapf(err, "item %d", len(prior)+i)
+		}
+		z := chain.next(P, R.X, item.Message)
+		ret.Rx = append(ret.Rx, R.X)
+		ret.S.Add(ret.S, z.Mul(z, s))
+	}
+	ret.S.Mod(ret.S, Curve.N)
+	return ret, nil
+}
+
+// VerifyHalfAggregate checks s*G == sum(z_i*R_i + z_i*e_i*P_i)
+func VerifyHalfAggregate(items []HalfAggItem, agg *HalfAggSignature) (bool, error) {
+	if agg == nil || agg.S == nil {
+		return false, errors.New("half-aggregate signature is empty")
+	}
+	if len(items) != len(agg.Rx) {
+		return false, errors.Errorf("half-aggregate signature has %d items, got %d", len(agg.Rx), len(items))
+	}
+	if err := ValidateScalar(agg.S); err != nil {
+		return false, errors.Wrap(err, "s")
+	}
+
+	var chain halfAggChain
+	var points []*Point
+	var scalars []*big.Int
+	for i, item := range items {
+		P, err := NewPoint(item.Px, item.Py)
+		if err != nil {
+			return false, errors.Wrapf(err, "public key of item %d", i)
+		}
+		Rx := agg.Rx[i]
+		Ry, err := curveY(Rx)
+		if err != nil {
+			return false, errors.Wrapf(err, "Rx of item %d", i)
+		}
+		z := chain.next(P, Rx, item.Message)
+		ze := getHash(P, Rx, item.Message)
+		ze.Mul(ze, z)
+		ze.Mod(ze, Curve.N)
+		points = append(points, &Point{X: Rx, Y: Ry}, P)
+		scalars = append(scalars, z, ze)
+	}
+	if !ScalarBaseMult(agg.S).Equal(multiScalarMult(points, scalars)) {
+		return false, errors.New("half-aggregate signature verification failed")
+	}
+	return true, nil
+}
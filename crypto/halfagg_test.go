@@ -0,0 +1,120 @@
+package crypto
+
+import (
+	"fmt"
+	"testing"
+)
+
+func halfAggItems(items []BatchItem) []HalfAggItem {
+	ret := make([]HalfAggItem, len(items))
+	for i, item := range items {
+		ret[i] = HalfAggItem{Px: item.Px, Py: item.Py, Message: item.Message}
+	}
+	return ret
+}
+
+func TestHalfAggregate(t *testing.T) {
+	items := newTestBatch(t, 10)
+	agg, err := HalfAggregate(items)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := VerifyHalfAggregate(halfAggItems(items), agg); !ok {
+		t.Fatal(err)
+	}
+
+	encoded := agg.Bytes()
+	if len(encoded) != 32*(len(items)+1) {
+		t.Errorf("encoded %d bytes, want %d", len(encoded), 32*(len(items)+1))
+	}
+	parsed, err := ParseHalfAggSignature(encoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := VerifyHalfAggregate(halfAggItems(items), parsed); !ok {
+		t.Error(err)
+	}
+
+	// swapping two items changes every later z_i
+	swapped := halfAggItems(items)
+	swapped[2], swapped[3] = swapped[3], swapped[2]
+	if ok, _ := VerifyHalfAggregate(swapped, agg); ok {
+		t.Error("reordered items should not verify")
+	}
+	forged := halfAggItems(items)
+	forged[7].Message = []byte("forged")
+	if ok, _ := VerifyHalfAggregate(forged, agg); ok {
+		t.Error("forged message should not verify")
+	}
+
+	items[4].Signature[40] ^= 1
+	bad, err := HalfAggregate(items)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, _ := VerifyHalfAggregate(halfAggItems(items), bad); ok {
+		t.Error("aggregate with a bad signature should not verify")
+	}
+}
+
+func TestIncrementalHalfAggregate(t *testing.T) {
+	items := newTestBatch(t, 9)
+	all, err := HalfAggregate(items)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	agg, err := HalfAggregate(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := VerifyHalfAggregate(nil, agg); !ok {
+		t.Fatal(err)
+	}
+	for _, cut := range [][2]int{{0, 4}, {4, 5}, {5, 9}} {
+		agg, err = IncrementalHalfAggregate(agg, halfAggItems(items[:cut[0]]), items[cut[0]:cut[1]])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ok, err := VerifyHalfAggregate(halfAggItems(items[:cut[1]]), agg); !ok {
+			t.Fatal(err)
+		}
+	}
+	if string(agg.Bytes()) != string(all.Bytes()) {
+		t.Error("incremental aggregate differs from aggregating at once")
+	}
+	if _, err := IncrementalHalfAggregate(agg, halfAggItems(items[:3]), items); err == nil {
+		t.Error("prior items must match the aggregate")
+	}
+}
+
+// Sizes for n signatures: 64*n bytes separately, 32*(n+1) half-aggregated
+func BenchmarkVerifyHalfAggregate(b *testing.B) {
+	for _, n := range []int{16, 128, 1024} {
+		items := newTestBatch(b, n)
+		agg, err := HalfAggregate(items)
+		if err != nil {
+			b.Fatal(err)
+		}
+		aggItems := halfAggItems(items)
+
+		b.Run(fmt.Sprintf("HalfAgg-%d", n), func(b *testing.B) {
+			b.ReportMetric(float64(len(agg.Bytes())), "sigbytes")
+			for i := 0; i < b.N; i++ {
+				if ok, err := VerifyHalfAggregate(aggItems, agg); !ok {
+					b.Fatal(err)
+				}
+			}
+		})
+		b.Run(fmt.Sprintf("Separate-%d", n), func(b *testing.B) {
+			b.ReportMetric(float64(64*n), "sigbytes")
+			for i := 0; i < b.N; i++ {
+				for _, item := range items {
+					if ok, err := VerifyMsg(item.Signature, item.Message, item.Px, item.Py); !ok {
+						b.Fatal(err)
+					}
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package crypto
+
+import (
+	"bytes"
+	"math/big"
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+// AggregateKey is the MuSig key X = sum(a_i*P_i) with a_i = H(L, P_i), or
+// after Tweak and XOnlyTweak the key Q = gacc*X + tacc*G
+type AggregateKey struct {
+	Point
+	// Coefficients[i] is a_i of PubKeys[i]
+	Coefficients []*big.Int
+	// PubKeys is the ordered list L the coefficients are bound to, signer
+	// indices of the sessions refer to this order
+	PubKeys [][]byte
+
+	// gacc is the product of the sign flips of the x-only tweaks, tacc the
+	// accumulated tweak, nil for an untweaked key
+	gacc, tacc *big.Int
+}
+
+type keyAggOptions struct {
+	keepOrder bool
+}
+
+// KeyAggOption changes the defaults of AggregateKeys
+type KeyAggOption func(*keyAggOptions)
+
+// KeepKeyOrder aggregates the public keys in the order of the caller instead
+// of sorting them, every cosigner then has to be given the same order
+func KeepKeyOrder() KeyAggOption {
+	return func(o *keyAggOptions) {
+		o.keepOrder = true
+	}
+}
+
+// SortKeys returns a copy of pubkeys in ascending byte order, the canonical L
+// that makes the aggregate key independent of the order the keys arrived in
+func SortKeys(pubkeys [][]byte) [][]byte {
+	keys := make([][]byte, len(pubkeys))
+	for i, pubkey := range pubkeys {
+		keys[i] = append([]byte{}, pubkey...)
+	}
+	sort.SliceStable(keys, func(i, j int) bool {
+		return bytes.Compare(keys[i], keys[j]) < 0
+	})
+	return keys
+}
+
+// AggregateKeys combines the PointMarshal encoded public keys into the MuSig
+// aggregate key, the keys are sorted with SortKeys first unless KeepKeyOrder is given
+func AggregateKeys(pubkeys [][]byte, opts ...KeyAggOption) (*AggregateKey, error) {
+	if len(pubkeys) == 0 {
+		return nil, errors.New("no public keys to aggregate")
+	}
+
+	var options keyAggOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+	var keys [][]byte
+	if options.keepOrder {
+		keys = make([][]byte, len(pubkeys))
+		for i, pubkey := range pubkeys {
+			keys[i] = append([]byte{}, pubkey...)
+		}
+	} else {
+		keys = SortKeys(pubkeys)
+	}
+	coefficients := getChallengeFactorList(keys)
+
+	agg := Infinity()
+	for i, key := range keys {
+		P, err := pointUnmarshal(key)
+		if err != nil {
+			return nil, errors.Wrapf(err, "public key %d", i)
+		}
+		agg = agg.Add(P.ScalarMult(coefficients[i]))
+	}
+	if agg.IsInfinity() {
+		return nil, errors.New("aggregate key is the point at infinity")
+	}
+
+	return &AggregateKey{
+		Point:        *agg,
+		Coefficients: coefficients,
+		PubKeys:      keys,
+	}, nil
+}
+
+// Index returns the position of pubkey in the ordered key list, or -1
+func (k *AggregateKey) Index(pubkey []byte) int {
+	for i, key := range k.PubKeys {
+		if string(key) == string(pubkey) {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,111 @@
+package crypto
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+)
+
+func TestAggregateKeys(t *testing.T) {
+	var publicKeyList [][]byte
+	var privateKeyList []*big.Int
+	for i := 0; i < 10; i++ {
+		Px, Py, pk := GenerateKeyPair()
+		publicKeyList = append(publicKeyList, PointMarshal(Px, Py))
+		privateKeyList = append(privateKeyList, pk)
+	}
+
+	key, err := AggregateKeys(publicKeyList, KeepKeyOrder())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(key.Coefficients) != 10 || len(key.PubKeys) != 10 {
+		t.Fatal("aggregate key should carry one coefficient per public key")
+	}
+
+	// sum(a_i*x_i)*G must be the aggregate key
+	x := new(big.Int)
+	for i, pk := range privateKeyList {
+		x.Add(x, new(big.Int).Mul(key.Coefficients[i], pk))
+		if key.Index(publicKeyList[i]) != i {
+			t.Errorf("index of public key %d is %d", i, key.Index(publicKeyList[i]))
+		}
+	}
+	x.Mod(x, Curve.N)
+	Px, Py := Curve.ScalarBaseMult(x.Bytes())
+	if Px.Cmp(key.X) != 0 || Py.Cmp(key.Y) != 0 {
+		t.Error("aggregate key is not sum(a_i*P_i)")
+	}
+}
+
+func TestAggregateKeysBindsKeyList(t *testing.T) {
+	var publicKeyList [][]byte
+	for i := 0; i < 3; i++ {
+		Px, Py, _ := GenerateKeyPair()
+		publicKeyList = append(publicKeyList, PointMarshal(Px, Py))
+	}
+
+	key, err := AggregateKeys(publicKeyList, KeepKeyOrder())
+	if err != nil {
+		t.Fatal(err)
+	}
+	subKey, err := AggregateKeys(publicKeyList[:2], KeepKeyOrder())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key.Coefficients[0].Cmp(subKey.Coefficients[0]) == 0 {
+		t.Error("coefficient should depend on the whole key list")
+	}
+
+	if _, err := AggregateKeys(nil); err == nil {
+		t.Error("empty key list should be rejected")
+	}
+}
+
+func TestAggregateKeysOrderIndependent(t *testing.T) {
+	var publicKeyList [][]byte
+	for i := 0; i < 5; i++ {
+		Px, Py, _ := GenerateKeyPair()
+		publicKeyList = append(publicKeyList, PointMarshal(Px, Py))
+	}
+	reversed := make([][]byte, len(publicKeyList))
+	for i, pubkey := range publicKeyList {
+		reversed[len(publicKeyList)-1-i] = pubkey
+	}
+
+	key, err := AggregateKeys(publicKeyList)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reversedKey, err := AggregateKeys(reversed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !key.Equal(&reversedKey.Point) {
+		t.Error("sorted aggregation should not depend on the key order")
+	}
+	sorted := SortKeys(reversed)
+	for i := range sorted {
+		if !bytes.Equal(key.PubKeys[i], sorted[i]) {
+			t.Fatal("PubKeys should be the sorted key list")
+		}
+		if i > 0 && bytes.Compare(sorted[i-1], sorted[i]) > 0 {
+			t.Fatal("SortKeys returned keys out of order")
+		}
+	}
+
+	keptKey, err := AggregateKeys(publicKeyList, KeepKeyOrder())
+	if err != nil {
+		t.Fatal(err)
+	}
+	keptReversed, err := AggregateKeys(reversed, KeepKeyOrder())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if keptKey.Equal(&keptReversed.Point) {
+		t.Error("KeepKeyOrder should bind the caller order")
+	}
+	if !bytes.Equal(keptReversed.PubKeys[0], reversed[0]) {
+		t.Error("KeepKeyOrder should not reorder PubKeys")
+	}
+}
@@ -0,0 +1,133 @@
+package crypto
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"math/big"
+
+	"github.com/pkg/errors"
+)
+
+// PublicKey is a point on Curve other than infinity
+type PublicKey struct {
+	Point
+}
+
+// PrivateKey is a scalar D in [1, N) together with its public key D*G. The
+// methods of the embedded PublicKey are those of the public key: Bytes
+// encodes the point D*G, SecretBytes is the only encoding of D.
+type PrivateKey struct {
+	PublicKey
+	D *big.Int
+}
+
+// Signature is the Rx||s pair produced by Sign and the signing sessions
+type Signature struct {
+	Rx, S *big.Int
+}
+
+// NewPrivateKey draws a random private key
+func NewPrivateKey() (*PrivateKey, error) {
+	key, err := ecdsa.GenerateKey(Curve, rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	return &PrivateKey{PublicKey: PublicKey{Point: Point{X: key.X, Y: key.Y}}, D: key.D}, nil
+}
+
+// NewPrivateKeyFromScalar wraps a raw scalar such as the pk of GenerateKeyPair
+func NewPrivateKeyFromScalar(D *big.Int) (*PrivateKey, error) {
+	if err := ValidateSecretScalar(D); err != nil {
+		return nil, errors.Wrap(err, "private key")
+	}
+	return &PrivateKey{PublicKey: PublicKey{Point: *ScalarBaseMult(D)}, D: new(big.Int).Set(D)}, nil
+}
+
+// ParsePrivateKey reads the 32-byte big endian encoding of SecretBytes
+func ParsePrivateKey(src []byte) (*PrivateKey, error) {
+	if len(src) != 32 {
+		return nil, errors.Errorf("private key must be 32 bytes, got %d", len(src))
+	}
+	return NewPrivateKeyFromScalar(new(big.Int).SetBytes(src))
+}
+
+// SecretBytes is the 32-byte big endian encoding of D. It is not called
+// Bytes, which k has from its PublicKey and which encodes the public point.
+func (k *PrivateKey) SecretBytes() []byte {
+	return scalarBytes(k.D)
+}
+
+// Public returns the public key of k
+func (k *PrivateKey) Public() *PublicKey {
+	return &PublicKey{Point: *k.Point.copy()}
+}
+
+// Sign signs message with a nonce from DeriveNonce
+func (k *PrivateKey) Sign(message []byte) (*Signature, error) {
+	sig, err := SignMsg(k.D, message)
+	if err != nil {
+		return nil, err
+	}
+	return ParseSignature(sig[:])
+}
+
+// NewPublicKey checks that X, Y is a point on Curve
+func NewPublicKey(X, Y *big.Int) (*PublicKey, error) {
+	P, err := NewPoint(X, Y)
+	if err != nil {
+		return nil, errors.Wrap(err, "public key")
+	}
+	return &PublicKey{Point: *P}, nil
+}
+
+// ParsePublicKey reads a public key in any encoding PointDecode accepts
+func ParsePublicKey(src []byte) (*PublicKey, error) {
+	Px, Py, err := PointDecode(src)
+	if err != nil {
+		return nil, err
+	}
+	return NewPublicKey(Px, Py)
+}
+
+// Verify checks sig over message
+func (p *PublicKey) Verify(message []byte, sig *Signature) (bool, error) {
+	if sig == nil {
+		return false, errors.New("signature is empty")
+	}
+	return verify(&p.Point, sig.Rx, sig.S, message)
+}
+
+// NewSignature checks that Rx is a field element and S a scalar
+func NewSignature(Rx, S *big.Int) (*Signature, error) {
+	if err := ValidateFieldElement(Rx); err != nil {
+		return nil, errors.Wrap(err, "signature Rx")
+	}
+	if err := ValidateScalar(S); err != nil {
+		return nil, errors.Wrap(err, "signature s")
+	}
+	return &Signature{Rx: new(big.Int).Set(Rx), S: new(big.Int).Set(S)}, nil
+}
+
+// ParseSignature reads the 64-byte Rx||s encoding
+func ParseSignature(src []byte) (*Signature, error) {
+	if len(src) != 64 {
+		return nil, errors.Errorf("signature must be 64 bytes, got %d", len(src))
+	}
+	return NewSignature(new(big.Int).SetBytes(src[:32]), new(big.Int).SetBytes(src[32:]))
+}
+
+// Bytes is the 64-byte Rx||s encoding
+func (s *Signature) Bytes() []byte {
+	sig := s.Array()
+	return sig[:]
+}
+
+// Array is the [64]byte form taken by VerifyMsg
+func (s *Signature) Array() [64]byte {
+	return signatureBytes(s.Rx, s.S)
+}
+
+// PublicKey of the aggregate key
+func (k *AggregateKey) PublicKey() *PublicKey {
+	return &PublicKey{Point: *k.Point.copy()}
+}
@@ -0,0 +1,86 @@
+package crypto
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+)
+
+func TestPrivateKeySignVerify(t *testing.T) {
+	key, err := NewPrivateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := []byte("typed keys")
+
+	sig, err := key.Sign(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := key.Public().Verify(msg, sig); !ok {
+		t.Error(err)
+	}
+	// the raw functions accept what the typed ones produce
+	if ok, err := VerifyMsg(sig.Array(), msg, key.X, key.Y); !ok {
+		t.Error(err)
+	}
+	if ok, _ := key.Public().Verify([]byte("other"), sig); ok {
+		t.Error("signature should not verify for another message")
+	}
+}
+
+func TestKeyEncoding(t *testing.T) {
+	key, err := NewPrivateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parsedKey, err := ParsePrivateKey(key.SecretBytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsedKey.D.Cmp(key.D) != 0 || parsedKey.X.Cmp(key.X) != 0 {
+		t.Error("private key changed after SecretBytes and ParsePrivateKey")
+	}
+	if !bytes.Equal(key.Bytes(), key.Public().Bytes()) {
+		t.Error("Bytes of a private key must encode the public key")
+	}
+
+	pub, err := ParsePublicKey(key.Public().Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(pub.Bytes(), PointMarshal(key.X, key.Y)) {
+		t.Error("public key changed after Bytes and ParsePublicKey")
+	}
+
+	sig, err := key.Sign([]byte("encoding"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsedSig, err := ParseSignature(sig.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsedSig.Rx.Cmp(sig.Rx) != 0 || parsedSig.S.Cmp(sig.S) != 0 {
+		t.Error("signature changed after Bytes and ParseSignature")
+	}
+}
+
+func TestKeyValidation(t *testing.T) {
+	if _, err := NewPrivateKeyFromScalar(new(big.Int)); err == nil {
+		t.Error("zero private key should be rejected")
+	}
+	if _, err := NewPrivateKeyFromScalar(Curve.N); err == nil {
+		t.Error("private key N should be rejected")
+	}
+	if _, err := NewPublicKey(big.NewInt(1), big.NewInt(1)); err == nil {
+		t.Error("point off the curve should be rejected")
+	}
+	if _, err := NewSignature(big.NewInt(1), Curve.N); err == nil {
+		t.Error("s = N should be rejected")
+	}
+	if _, err := ParseSignature(make([]byte, 63)); err == nil {
+		t.Error("short signature should be rejected")
+	}
+}
@@ -0,0 +1,238 @@
+package crypto
+
+import (
+	"math/big"
+	"math/bits"
+)
+
+// Multi-scalar multiplication sum(k_i*P_i) with Pippenger's bucket method in
+// Jacobian coordinates, so that no field inversion is needed until the end.
+// Curve.Add goes through affine coordinates and pays one inversion per call.
+
+// (x, y, z) is the affine point (x/z^2, y/z^3), z == 0 is the point at infinity
+type jacobianPoint struct {
+	x, y, z fieldElement
+}
+
+func (p *jacobianPoint) isInfinity() bool {
+	return p.z.isZero()
+}
+
+// dbl-2009-l, a = 0
+func (p *jacobianPoint) double() {
+	if p.isInfinity() || p.y.isZero() {
+		p.z = fieldElement{}
+		return
+	}
+	A := fieldSqr(&p.x)
+	B := fieldSqr(&p.y)
+	C := fieldSqr(&B)
+
+	D := fieldAdd(&p.x, &B)
+	D = fieldSqr(&D)
+	D = fieldSub(&D, &A)
+	D = fieldSub(&D, &C)
+	D = fieldAdd(&D, &D)
+
+	E := fieldAdd(&A, &A)
+	E = fieldAdd(&E, &A)
+	F := fieldSqr(&E)
+
+	x3 := fieldAdd(&D, &D)
+	x3 = fieldSub(&F, &x3)
+
+	C8 := fieldAdd(&C, &C)
+	C8 = fieldAdd(&C8, &C8)
+	C8 = fieldAdd(&C8, &C8)
+	y3 := fieldSub(&D, &x3)
+	y3 = fieldMul(&E, &y3)
+	y3 = fieldSub(&y3, &C8)
+
+	z3 := fieldMul(&p.y, &p.z)
+	z3 = fieldAdd(&z3, &z3)
+
+	p.x, p.y, p.z = x3, y3, z3
+}
+
+// add-2007-bl, p += q
+func (p *jacobianPoint) add(q *jacobianPoint) {
+	if q.isInfinity() {
+		return
+	}
+	if p.isInfinity() {
+		*p = *q
+		return
+	}
+	Z1Z1 := fieldSqr(&p.z)
+	Z2Z2 := fieldSqr(&q.z)
+	U1 := fieldMul(&p.x, &Z2Z2)
+	U2 := fieldMul(&q.x, &Z1Z1)
+	S1 := fieldMul(&p.y, &q.z)
+	S1 = fieldMul(&S1, &Z2Z2)
+	S2 := fieldMul(&q.y, &p.z)
+	S2 = fieldMul(&S2, &Z1Z1)
+
+	H := fieldSub(&U2, &U1)
+	r := fieldSub(&S2, &S1)
+	if H.isZero() {
+		if r.isZero() {
+			p.double()
+		} else {
+			p.z = fieldElement{}
+		}
+		return
+	}
+	r = fieldAdd(&r, &r)
+
+	I := fieldAdd(&H, &H)
+	I = fieldSqr(&I)
+	J := fieldMul(&H, &I)
+	V := fieldMul(&U1, &I)
+
+	x3 := fieldSqr(&r)
+	x3 = fieldSub(&x3, &J)
+	x3 = fieldSub(&x3, &V)
+	x3 = fieldSub(&x3, &V)
+
+	y3 := fieldSub(&V, &x3)
+	y3 = fieldMul(&r, &y3)
+	S1J := fieldMul(&S1, &J)
+	y3 = fieldSub(&y3, &S1J)
+	y3 = fieldSub(&y3, &S1J)
+
+	z3 := fieldAdd(&p.z, &q.z)
+	z3 = fieldSqr(&z3)
+	z3 = fieldSub(&z3, &Z1Z1)
+	z3 = fieldSub(&z3, &Z2Z2)
+	z3 = fieldMul(&z3, &H)
+
+	p.x, p.y, p.z = x3, y3, z3
+}
+
+// madd-2007-bl, p += (x, y, 1)
+func (p *jacobianPoint) addAffine(x, y *fieldElement) {
+	if p.isInfinity() {
+		p.x, p.y, p.z = *x, *y, fieldElement{1}
+		return
+	}
+	Z1Z1 := fieldSqr(&p.z)
+	U2 := fieldMul(x, &Z1Z1)
+	S2 := fieldMul(y, &p.z)
+	S2 = fieldMul(&S2, &Z1Z1)
+
+	H := fieldSub(&U2, &p.x)
+	r := fieldSub(&S2, &p.y)
+	if H.isZero() {
+		if r.isZero() {
+			p.double()
+		} else {
+			p.z = fieldElement{}
+		}
+		return
+	}
+	r = fieldAdd(&r, &r)
+
+	HH := fieldSqr(&H)
+	I := fieldAdd(&HH, &HH)
+	I = fieldAdd(&I, &I)
+	J := fieldMul(&H, &I)
+	V := fieldMul(&p.x, &I)
+
+	x3 := fieldSqr(&r)
+	x3 = fieldSub(&x3, &J)
+	x3 = fieldSub(&x3, &V)
+	x3 = fieldSub(&x3, &V)
+
+	y3 := fieldSub(&V, &x3)
+	y3 = fieldMul(&r, &y3)
+	Y1J := fieldMul(&p.y, &J)
+	y3 = fieldSub(&y3, &Y1J)
+	y3 = fieldSub(&y3, &Y1J)
+
+	z3 := fieldAdd(&p.z, &H)
+	z3 = fieldSqr(&z3)
+	z3 = fieldSub(&z3, &Z1Z1)
+	z3 = fieldSub(&z3, &HH)
+
+	p.x, p.y, p.z = x3, y3, z3
+}
+
+// affine returns (0, 0) for the point at infinity, like Curve.Add does
+func (p *jacobianPoint) affine() (x, y *big.Int) {
+	if p.isInfinity() {
+		return new(big.Int), new(big.Int)
+	}
+	zInv := fieldFromBig(new(big.Int).ModInverse(p.z.big(), Curve.P))
+	zInv2 := fieldSqr(&zInv)
+	zInv3 := fieldMul(&zInv2, &zInv)
+	ax := fieldMul(&p.x, &zInv2)
+	ay := fieldMul(&p.y, &zInv3)
+	return ax.big(), ay.big()
+}
+
+// sum(scalars[i] * (xs[i], ys[i])), the points must be on the curve and the scalars in [0, N)
+func multiScalarMult(points []*Point, scalars []*big.Int) *Point {
+	maxBits := 0
+	for _, k := range scalars {
+		if k.BitLen() > maxBits {
+			maxBits = k.BitLen()
+		}
+	}
+	if maxBits == 0 {
+		return Infinity()
+	}
+
+	fxs := make([]fieldElement, len(points))
+	fys := make([]fieldElement, len(points))
+	for i, P := range points {
+		if !P.IsInfinity() {
+			fxs[i], fys[i] = fieldFromBig(P.X), fieldFromBig(P.Y)
+		}
+	}
+
+	// window size close to log2(n) balances bucket filling against bucket summing
+	c := bits.Len(uint(len(scalars))) - 2
+	if c < 2 {
+		c = 2
+	} else if c > 16 {
+		c = 16
+	}
+
+	var acc jacobianPoint
+	buckets := make([]jacobianPoint, 1<<uint(c))
+	for window := (maxBits - 1) / c; window >= 0; window-- {
+		for i := 0; i < c; i++ {
+			acc.double()
+		}
+
+		for i := range buckets {
+			buckets[i] = jacobianPoint{}
+		}
+		for i, k := range scalars {
+			digit := scalarWindow(k, window*c, c)
+			if digit == 0 || (fxs[i].isZero() && fys[i].isZero()) {
+				continue
+			}
+			buckets[digit].addAffine(&fxs[i], &fys[i])
+		}
+
+		// sum(d * bucket[d]) as a running sum from the highest bucket down
+		var running, windowSum jacobianPoint
+		for d := len(buckets) - 1; d > 0; d-- {
+			running.add(&buckets[d])
+			windowSum.add(&running)
+		}
+		acc.add(&windowSum)
+	}
+	x, y := acc.affine()
+	return &Point{X: x, Y: y}
+}
+
+// the c bits of k starting at bit offset
+func scalarWindow(k *big.Int, offset, c int) int {
+	digit := 0
+	for i := c - 1; i >= 0; i-- {
+		digit = digit<<1 | int(k.Bit(offset+i))
+	}
+	return digit
+}
@@ -9,20 +9,18 @@ import (
 )
 
 //Hash(R_i) in round one
-func getHashRi(Rx, Ry *big.Int) (string, error) {
-	bRx := Rx.Bytes()
-	bRy := Ry.Bytes()
-	if len(bRx) != 32 || len(bRy) != 32 {
+func getHashRi(R *Point) (string, error) {
+	if R.IsInfinity() || R.X.BitLen() > 256 || R.Y.BitLen() > 256 {
 		return "", errors.New("length of R not correct")
 	}
 
-	payload := append(bRx, bRy...)
+	payload := R.Bytes()
 	hashed := sha256.Sum256(payload)
 	return string(hex.EncodeToString(hashed[:])), nil
 }
 
-func verifyHashRi(Rx, Ry *big.Int, hashedRi string) (bool, error) {
-	verifyHash, err := getHashRi(Rx, Ry)
+func verifyHashRi(R *Point, hashedRi string) (bool, error) {
+	verifyHash, err := getHashRi(R)
 	if err != nil {
 		return false, err
 	}
@@ -39,9 +37,7 @@ func getChallengeFactor(Points [][]byte, pubKey []byte, pk *big.Int) *big.Int {
 		L = append(L, point...)
 	}
 
-	var LPi []byte
-	copy(LPi, L)
-	LPi = append(LPi, pubKey...)
+	LPi := append(L, pubKey...)
 	hashed := sha256.Sum256(LPi)
 	i := new(big.Int).SetBytes(hashed[:])
 	i.Mul(i, pk)
@@ -56,9 +52,7 @@ func getChallengeFactorByIndex(Points [][]byte, index int) *big.Int {
 		L = append(L, point...)
 	}
 
-	var LPi []byte
-	copy(LPi, L)
-	LPi = append(LPi, Points[index]...)
+	LPi := append(L, Points[index]...)
 	hashed := sha256.Sum256(LPi)
 	i := new(big.Int).SetBytes(hashed[:])
 	//	i.Mul(i, pk)
@@ -66,26 +60,25 @@ func getChallengeFactorByIndex(Points [][]byte, index int) *big.Int {
 	return i
 }
 
-func getAggregatePoints(points [][]byte) (aggPx, aggPy *big.Int, err error) {
-	aggPx, aggPy = new(big.Int), new(big.Int)
+func getAggregatePoints(points [][]byte) (*Point, error) {
+	agg := Infinity()
 	for _, point := range points {
-		Px, Py, err := PointUnmarshal(point)
+		P, err := pointUnmarshal(point)
 		if err != nil {
-			return nil, nil, err
+			return nil, err
 		}
-		aggPx, aggPy = Curve.Add(aggPx, aggPy, Px, Py)
+		agg = agg.Add(P)
 	}
-	return aggPx, aggPy, nil
+	return agg, nil
 }
 
-func generateMemberSignature(pkChallengeFactor, r, aggRx, aggRy, aggPx, aggPy *big.Int, message []byte) (s *big.Int) {
-	r0 := r
-	if big.Jacobi(aggRy, Curve.P) != 1 {
-		aggRy.Sub(Curve.P, aggRy)
+func generateMemberSignature(pkChallengeFactor, r *big.Int, aggR, aggP *Point, message []byte) (s *big.Int) {
+	r0 := new(big.Int).Set(r)
+	if !aggR.hasSquareY() {
 		r0.Sub(Curve.N, r0)
 	}
 
-	hashedNum := getHash(aggPx, aggPy, aggRx, message)
+	hashedNum := getHash(aggP, aggR.X, message)
 	hashedNum.Mul(hashedNum, pkChallengeFactor)
 
 	r0.Add(r0, hashedNum)
@@ -120,9 +113,8 @@ func getChallengeFactorList(Points [][]byte) []*big.Int {
 
 	var challengeFactorList []*big.Int
 	for _, point := range Points {
-		var hashPayload []byte
-		copy(hashPayload, L[:])
-		hashPayload = append(hashPayload, point...)
+		//bug 4. 曾用copy把L拷进空切片，什么都没拷进去，a_i退化成了H(P_i)，没有绑定整个公钥列表
+		hashPayload := append(L[:len(L):len(L)], point...)
 		hashedBytes := sha256.Sum256(hashPayload)
 		challengeFactor := new(big.Int).SetBytes(hashedBytes[:])
 		challengeFactor.Mod(challengeFactor, Curve.N)
@@ -145,8 +137,8 @@ func TempMusig() {
 	}
 
 	//message := []byte("message")
-	Rx, Ry := new(big.Int), new(big.Int)
-	Px, Py := new(big.Int), new(big.Int)
+	R := Infinity()
+	P := Infinity()
 	var publicKeyList [][]byte
 	var publicRandomList [][]byte
 
@@ -154,17 +146,14 @@ func TempMusig() {
 		privateKey := privateKeyList[i]
 		privateRandom := privateRandomList[i]
 
-		PiX, PiY := Curve.ScalarBaseMult(privateKey.Bytes())
-		RiX, RiY := Curve.ScalarBaseMult(privateRandom.Bytes())
+		Pi := ScalarBaseMult(privateKey)
+		Ri := ScalarBaseMult(privateRandom)
 
-		Rx, Ry = Curve.Add(Rx, Ry, RiX, RiY)
-		Px, Py = Curve.Add(Px, Py, PiX, PiY)
+		R = R.Add(Ri)
+		P = P.Add(Pi)
 
-		bytePi := PointMarshal(PiX, PiY)
-		byteRi := PointMarshal(RiX, RiY)
-
-		publicKeyList = append(publicKeyList, bytePi)
-		publicRandomList = append(publicRandomList, byteRi)
+		publicKeyList = append(publicKeyList, Pi.Bytes())
+		publicRandomList = append(publicRandomList, Ri.Bytes())
 	}
 
 	challengeFactorList := getChallengeFactorList(publicKeyList)
@@ -174,37 +163,33 @@ func TempMusig() {
 
 	for i := 0; i < 10; i++ {
 		mpk := new(big.Int).Mul(privateKeyList[i], challengeFactorList[i])
-		PiX, PiY, _ := PointUnmarshal(publicKeyList[i])
-		mPiX, mPiY := Curve.ScalarMult(PiX, PiY, challengeFactorList[i].Bytes())
+		Pi, _ := pointUnmarshal(publicKeyList[i])
 
 		memberPrivateKeyList = append(memberPrivateKeyList, mpk)
-		memberPublicKeyList = append(memberPublicKeyList, PointMarshal(mPiX, mPiY))
+		memberPublicKeyList = append(memberPublicKeyList, Pi.ScalarMult(challengeFactorList[i]).Bytes())
 	}
 
-	aggMemPx, aggMemPy := new(big.Int).SetInt64(0), new(big.Int).SetInt64(0)
-	aggPx, aggPy := new(big.Int).SetInt64(0), new(big.Int).SetInt64(0)
-	aggRx, aggRy := new(big.Int).SetInt64(0), new(big.Int).SetInt64(0)
+	aggMemP := Infinity()
+	aggP := Infinity()
+	aggR := Infinity()
 	for i := 0; i < 10; i++ {
-		memPiX, memPiY, _ := PointUnmarshal(memberPublicKeyList[i])
-		aggMemPx, aggMemPy = Curve.Add(aggMemPx, aggMemPy, memPiX, memPiY)
-		RiX, RiY, err := PointUnmarshal(publicRandomList[i])
-		if err != nil {
-			fmt.Errorf(err.Error())
-		}
-		aggRx, aggRy = Curve.Add(aggRx, aggRy, RiX, RiY)
-		PiX, PiY, _ := PointUnmarshal(publicKeyList[i])
-		aggPx, aggPy = Curve.Add(aggPx, aggPy, PiX, PiY)
+		memPi, _ := pointUnmarshal(memberPublicKeyList[i])
+		aggMemP = aggMemP.Add(memPi)
+		Ri, _ := pointUnmarshal(publicRandomList[i])
+		aggR = aggR.Add(Ri)
+		Pi, _ := pointUnmarshal(publicKeyList[i])
+		aggP = aggP.Add(Pi)
 	}
 
 	message := []byte("msg for signing")
-	e := getHash(aggPx, aggPy, aggRx, message)
+	e := getHash(aggP, aggR.X, message)
 	s := new(big.Int).SetInt64(0)
 	for i := 0; i < 10; i++ {
 		si := new(big.Int).SetInt64(0)
 		r0 := privateRandomList[i]
 		r := new(big.Int).Set(r0)
 
-		if big.Jacobi(aggRy, Curve.P) != 1 {
+		if !aggR.hasSquareY() {
 			r.Sub(Curve.N, r)
 		}
 
@@ -224,19 +209,18 @@ func TempMusig() {
 
 	//	t.Log(hex.EncodeToString(s.Bytes()))
 
-	sGx, sGy := Curve.ScalarBaseMult(s.Bytes())
-	ePx, ePy := Curve.ScalarMult(aggMemPx, aggMemPy, e.Bytes())
-	ePy.Sub(Curve.P, ePy)
-	cRx, cRy := Curve.Add(sGx, sGy, ePx, ePy)
+	sG := ScalarBaseMult(s)
+	eP := aggMemP.ScalarMult(e).Neg()
+	cR := sG.Add(eP)
 
 	//bug 2. 曾将验证过程写成和Curve.N的Jacobi
-	fmt.Println(big.Jacobi(cRy, Curve.P))
-
-	fmt.Println(hex.EncodeToString(sGx.Bytes()))
-	fmt.Println(hex.EncodeToString(sGy.Bytes()))
-	fmt.Println(hex.EncodeToString(ePx.Bytes()))
-	fmt.Println(hex.EncodeToString(ePy.Bytes()))
-	fmt.Println(hex.EncodeToString(aggRx.Bytes()))
-	fmt.Println(hex.EncodeToString(cRx.Bytes()))
-	fmt.Println(hex.EncodeToString(cRy.Bytes()))
+	fmt.Println(big.Jacobi(cR.Y, Curve.P))
+
+	fmt.Println(hex.EncodeToString(sG.X.Bytes()))
+	fmt.Println(hex.EncodeToString(sG.Y.Bytes()))
+	fmt.Println(hex.EncodeToString(eP.X.Bytes()))
+	fmt.Println(hex.EncodeToString(eP.Y.Bytes()))
+	fmt.Println(hex.EncodeToString(aggR.X.Bytes()))
+	fmt.Println(hex.EncodeToString(cR.X.Bytes()))
+	fmt.Println(hex.EncodeToString(cR.Y.Bytes()))
 }
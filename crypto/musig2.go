@@ -0,0 +1,271 @@
+package crypto
+
+import (
+	"crypto/sha256"
+	"math/big"
+
+	"github.com/pkg/errors"
+)
+
+// MuSig2 drops the commitment round of the original flow: every signer
+// publishes two nonce points R1_i, R2_i, which can be done before the
+// message is known, and the final nonce is R = R1 + b*R2 with
+// b = H(X, R1, R2, m).
+
+// Musig2Nonce is the secret nonce pair (k1, k2) of a signer, it must only be used in one session
+type Musig2Nonce struct {
+	k1, k2 *big.Int
+	// R1 = k1*G and R2 = k2*G, PointMarshal encoded
+	R1, R2 []byte
+}
+
+// GenerateMusig2Nonce derives a fresh nonce pair with DeriveNonce, message
+// may be nil when the nonce is preprocessed before the message is known
+func GenerateMusig2Nonce(privKey *big.Int, aggKey, message []byte) (*Musig2Nonce, error) {
+	k1, err := deriveNonce(privKey, message, aggKey, 1)
+	if err != nil {
+		return nil, err
+	}
+	k2, err := deriveNonce(privKey, message, aggKey, 2)
+	if err != nil {
+		return nil, err
+	}
+	return &Musig2Nonce{
+		k1: k1,
+		k2: k2,
+		R1: ScalarBaseMult(k1).Bytes(),
+		R2: ScalarBaseMult(k2).Bytes(),
+	}, nil
+}
+
+// b = H(X, R1, R2, m)
+func getMusig2NonceCoefficient(aggP, R1, R2 *Point, message []byte) *big.Int {
+	payload := aggP.Bytes()
+	payload = append(payload, R1.Bytes()...)
+	payload = append(payload, R2.Bytes()...)
+	payload = append(payload, message...)
+	hashed := sha256.Sum256(payload)
+	b := new(big.Int).SetBytes(hashed[:])
+	return b.Mod(b, Curve.N)
+}
+
+// Musig2Session drives one signer through nonce exchange -> partial-sign -> aggregate
+type Musig2Session struct {
+	index   int
+	privKey *big.Int
+	message []byte
+	key     *AggregateKey
+	nonce   *Musig2Nonce
+
+	round    int
+	nonces1  [][]byte
+	nonces2  [][]byte
+	partials []*big.Int
+
+	b    *big.Int
+	aggR *Point
+	e    *big.Int
+	// R1_i + b*R2_i of every signer
+	nonces [][]byte
+
+	nonceStore NonceStore
+}
+
+// NewMusig2Session starts a MuSig2 session for the owner of privKey with a nonce
+// from GenerateMusig2Nonce, WithNonceStore is the only SessionOption it takes
+func NewMusig2Session(key *AggregateKey, privKey *big.Int, nonce *Musig2Nonce, message []byte, opts ...SessionOption) (*Musig2Session, error) {
+	if key == nil || len(key.PubKeys) == 0 {
+		return nil, errors.New("no public keys for session")
+	}
+	if err := ValidateSecretScalar(privKey); err != nil {
+		return nil, errors.Wrap(err, "private key")
+	}
+	if nonce == nil || nonce.k1 == nil || nonce.k2 == nil {
+		return nil, errors.New("nonce is empty or already used")
+	}
+
+	var options sessionOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+	if options.nonce != nil || options.adaptor != nil {
+		return nil, errors.New("MuSig2 sessions only take WithNonceStore")
+	}
+
+	index := key.Index(ScalarBaseMult(privKey).Bytes())
+	if index < 0 {
+		return nil, errors.New("public key of signer not in public key list")
+	}
+
+	s := &Musig2Session{
+		index:   index,
+		privKey: privKey,
+		message: message,
+		key:     key,
+		nonce:   nonce,
+		// there is no commit round, nonces are exchanged in the reveal round
+		round:    roundReveal,
+		nonces1:  make([][]byte, len(key.PubKeys)),
+		nonces2:  make([][]byte, len(key.PubKeys)),
+		partials: make([]*big.Int, len(key.PubKeys)),
+
+		nonceStore: options.nonceStore,
+	}
+	s.nonces1[index] = nonce.R1
+	s.nonces2[index] = nonce.R2
+	if err := s.advance(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Index of the signer in the public key list
+func (s *Musig2Session) Index() int {
+	return s.index
+}
+
+// AggregateKey is the key the final signature verifies against
+func (s *Musig2Session) AggregateKey() *AggregateKey {
+	return s.key
+}
+
+// AddNonce records the public nonce pair of a cosigner
+func (s *Musig2Session) AddNonce(index int, R1, R2 []byte) error {
+	if s.round != roundReveal {
+		return errors.New("nonces are only accepted in the nonce round")
+	}
+	if err := checkCosigner(index, s.index, len(s.key.PubKeys)); err != nil {
+		return err
+	}
+	if s.nonces1[index] != nil {
+		return errors.Errorf("nonce of signer %d already received", index)
+	}
+	if _, err := pointUnmarshal(R1); err != nil {
+		return errors.Wrapf(err, "nonce R1 of signer %d", index)
+	}
+	if _, err := pointUnmarshal(R2); err != nil {
+		return errors.Wrapf(err, "nonce R2 of signer %d", index)
+	}
+	s.nonces1[index] = R1
+	s.nonces2[index] = R2
+	return s.advance()
+}
+
+// advance moves the session on for as long as the data of the current round is complete
+func (s *Musig2Session) advance() error {
+	if s.round == roundReveal {
+		for _, R := range s.nonces1 {
+			if R == nil {
+				return nil
+			}
+		}
+		if err := s.aggregateNonces(); err != nil {
+			return err
+		}
+		s.round = roundPartialSign
+	}
+	if s.round == roundPartialSign && allPartials(s.partials) {
+		s.round = roundAggregate
+	}
+	return nil
+}
+
+// R = R1 + b*R2
+func (s *Musig2Session) aggregateNonces() error {
+	R1, err := getAggregatePoints(s.nonces1)
+	if err != nil {
+		return err
+	}
+	R2, err := getAggregatePoints(s.nonces2)
+	if err != nil {
+		return err
+	}
+	b := getMusig2NonceCoefficient(&s.key.Point, R1, R2, s.message)
+	aggR := R1.Add(R2.ScalarMult(b))
+	if aggR.IsInfinity() {
+		return errors.New("aggregate nonce is the point at infinity")
+	}
+
+	s.nonces = make([][]byte, len(s.nonces1))
+	for i := range s.nonces1 {
+		R1i, err := pointUnmarshal(s.nonces1[i])
+		if err != nil {
+			return err
+		}
+		R2i, err := pointUnmarshal(s.nonces2[i])
+		if err != nil {
+			return err
+		}
+		s.nonces[i] = R1i.Add(R2i.ScalarMult(b)).Bytes()
+	}
+
+	s.b = b
+	s.aggR = aggR
+	s.e = getHash(&s.key.Point, aggR.X, s.message)
+	return nil
+}
+
+// PartialSign returns s_i = k1 + b*k2 + H(X, Rx, m)*a_i*x_i, the nonce is consumed
+func (s *Musig2Session) PartialSign() (*big.Int, error) {
+	if s.round < roundPartialSign {
+		return nil, errors.New("can not sign before all nonces are received")
+	}
+	if s.partials[s.index] != nil {
+		return new(big.Int).Set(s.partials[s.index]), nil
+	}
+	if s.nonce.k1 == nil || s.nonce.k2 == nil {
+		return nil, ErrNonceReused
+	}
+	if s.nonceStore != nil {
+		if err := s.nonceStore.Consume(append(append([]byte{}, s.nonce.R1...), s.nonce.R2...)); err != nil {
+			return nil, err
+		}
+	}
+
+	r := new(big.Int).Mul(s.b, s.nonce.k2)
+	r.Add(r, s.nonce.k1)
+	r.Mod(r, Curve.N)
+	s.nonce.k1, s.nonce.k2 = nil, nil
+
+	pkChallengeFactor := new(big.Int).Mul(s.privKey, s.key.signerFactor(s.index))
+	si := generateMemberSignature(pkChallengeFactor, r, s.aggR, &s.key.Point, s.message)
+	s.partials[s.index] = si
+	if err := s.advance(); err != nil {
+		return nil, err
+	}
+	return new(big.Int).Set(si), nil
+}
+
+// AddPartialSignature records s_i of a cosigner
+func (s *Musig2Session) AddPartialSignature(index int, si *big.Int) error {
+	if s.round != roundPartialSign {
+		return errors.New("partial signatures are only accepted in the partial-sign round")
+	}
+	if err := checkCosigner(index, s.index, len(s.key.PubKeys)); err != nil {
+		return err
+	}
+	if s.partials[index] != nil {
+		return errors.Errorf("partial signature of signer %d already received", index)
+	}
+	negate := !s.aggR.hasSquareY()
+	if err := verifyPartialAt(s.key, index, s.nonces[index], negate, si, s.e); err != nil {
+		return &PartialSignatureError{Signers: []int{index}}
+	}
+	s.partials[index] = new(big.Int).Set(si)
+	return s.advance()
+}
+
+// Signature aggregates the partial signatures into Rx||s, it verifies against AggregateKey
+func (s *Musig2Session) Signature() ([64]byte, error) {
+	if s.round != roundAggregate {
+		return [64]byte{}, errors.New("can not aggregate before all partial signatures are received")
+	}
+	aggS, err := verifyPartials(s.key, s.nonces, s.aggR, s.partials, s.message)
+	if err != nil {
+		return [64]byte{}, err
+	}
+	if _, err := verify(&s.key.Point, s.aggR.X, aggS, s.message); err != nil {
+		return [64]byte{}, errors.Wrap(err, "aggregate signature")
+	}
+	return signatureBytes(s.aggR.X, aggS), nil
+}
@@ -0,0 +1,96 @@
+package crypto
+
+import (
+	"math/big"
+	"testing"
+)
+
+func newTestMusig2Sessions(t *testing.T, n int, message []byte) []*Musig2Session {
+	var publicKeyList [][]byte
+	var privateKeyList []*big.Int
+	for i := 0; i < n; i++ {
+		Px, Py, pk := GenerateKeyPair()
+		publicKeyList = append(publicKeyList, PointMarshal(Px, Py))
+		privateKeyList = append(privateKeyList, pk)
+	}
+	key, err := AggregateKeys(publicKeyList)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// sessions[i] is the signer of key.PubKeys[i]
+	sessions := make([]*Musig2Session, n)
+	for i := 0; i < n; i++ {
+		// nonces are preprocessed before the message is fixed
+		nonce, err := GenerateMusig2Nonce(privateKeyList[i], key.Bytes(), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		session, err := NewMusig2Session(key, privateKeyList[i], nonce, message)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sessions[session.Index()] = session
+	}
+	return sessions
+}
+
+func TestMusig2Session(t *testing.T) {
+	message := []byte("msg for signing")
+	sessions := newTestMusig2Sessions(t, 5, message)
+
+	for _, session := range sessions {
+		for _, other := range sessions {
+			if other == session {
+				continue
+			}
+			if err := session.AddNonce(other.Index(), other.nonce.R1, other.nonce.R2); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	var partials []*big.Int
+	for _, session := range sessions {
+		si, err := session.PartialSign()
+		if err != nil {
+			t.Fatal(err)
+		}
+		partials = append(partials, si)
+	}
+	for _, session := range sessions {
+		for j, si := range partials {
+			if j == session.Index() {
+				continue
+			}
+			if err := session.AddPartialSignature(j, si); err != nil {
+				t.Fatal(err)
+			}
+		}
+		signature, err := session.Signature()
+		if err != nil {
+			t.Fatal(err)
+		}
+		key := session.AggregateKey()
+		if ok, err := VerifyMsg(signature, message, key.X, key.Y); !ok {
+			t.Error(err)
+		}
+	}
+}
+
+func TestMusig2NonceSingleUse(t *testing.T) {
+	sessions := newTestMusig2Sessions(t, 2, []byte("single use"))
+	if _, err := sessions[0].PartialSign(); err == nil {
+		t.Error("partial sign should wait for all nonces")
+	}
+	if err := sessions[0].AddNonce(1, sessions[1].nonce.R1, sessions[1].nonce.R2); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sessions[0].PartialSign(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := NewMusig2Session(sessions[0].key, sessions[0].privKey, sessions[0].nonce, []byte("other")); err == nil {
+		t.Error("a used nonce should not start another session")
+	}
+}
@@ -9,14 +9,14 @@ import (
 
 func TestHashRi(t *testing.T) {
 	Rx, Ry, _ := GenerateKeyPair()
-	hashedRi, err := getHashRi(Rx, Ry)
+	hashedRi, err := getHashRi(&Point{X: Rx, Y: Ry})
 
 	if err != nil {
 		t.Error(err)
 	}
 	t.Log(hashedRi)
 
-	_, err = verifyHashRi(Rx, Ry, hashedRi)
+	_, err = verifyHashRi(&Point{X: Rx, Y: Ry}, hashedRi)
 	if err != nil {
 		t.Error(err)
 	}
@@ -46,11 +46,11 @@ func TestPointAggregation(t *testing.T) {
 		publicKeyList = append(publicKeyList, PointMarshal(Px, Py))
 		privateKeyList = append(privateKeyList, pk)
 	}
-	aggX, aggY, err := getAggregatePoints(publicKeyList)
+	agg, err := getAggregatePoints(publicKeyList)
 	if err != nil {
 		t.Error(err)
 	}
-	t.Log(fmt.Sprintf("key aggreation, with X = %s, Y = %s", hex.EncodeToString(aggX.Bytes()), hex.EncodeToString(aggY.Bytes())))
+	t.Log(fmt.Sprintf("key aggreation, with X = %s, Y = %s", hex.EncodeToString(agg.X.Bytes()), hex.EncodeToString(agg.Y.Bytes())))
 }
 
 func TestGetMembershipSignature(t *testing.T) {
@@ -68,15 +68,15 @@ func TestGetMembershipSignature(t *testing.T) {
 		RList = append(RList, PointMarshal(Rx, Ry))
 		rList = append(rList, r)
 	}
-	aggPx, aggPy, err := getAggregatePoints(publicKeyList)
-	aggRx, aggRy, err := getAggregatePoints(RList)
+	aggP, err := getAggregatePoints(publicKeyList)
+	aggR, err := getAggregatePoints(RList)
 
 	if err != nil {
 		t.Error(err)
 	}
 
 	message := []byte("fuickyou")
-	generateMemberSignature(getChallengeFactor(publicKeyList, publicKeyList[0], privateKeyList[0]), rList[0], aggRx, aggRy, aggPx, aggPy, message)
+	generateMemberSignature(getChallengeFactor(publicKeyList, publicKeyList[0], privateKeyList[0]), rList[0], aggR, aggP, message)
 
 }
 
@@ -94,8 +94,8 @@ func TestMainTest(t *testing.T) {
 		RList = append(RList, PointMarshal(Rx, Ry))
 		rList = append(rList, r)
 	}
-	aggPx, aggPy, err := getAggregatePoints(publicKeyList)
-	aggRx, aggRy, err := getAggregatePoints(RList)
+	aggP, err := getAggregatePoints(publicKeyList)
+	aggR, err := getAggregatePoints(RList)
 
 	if err != nil {
 		t.Error(err)
@@ -106,7 +106,7 @@ func TestMainTest(t *testing.T) {
 	var aggSlist []*big.Int
 
 	for i := 0; i < 10; i++ {
-		aggSTemp := generateMemberSignature(getChallengeFactor(publicKeyList, publicKeyList[0], privateKeyList[0]), rList[0], aggRx, aggRy, aggPx, aggPy, message)
+		aggSTemp := generateMemberSignature(getChallengeFactor(publicKeyList, publicKeyList[0], privateKeyList[0]), rList[0], aggR, aggP, message)
 		aggSlist = append(aggSlist, aggSTemp)
 	}
 	aggSCalc := aggreateMemberSignature(aggSlist)
@@ -116,7 +116,7 @@ func TestMainTest(t *testing.T) {
 
 	verX, verY := new(big.Int), new(big.Int)
 
-	verHashedNum := getHash(aggPx, aggPy, aggRx, message)
+	verHashedNum := getHash(aggP, aggR.X, message)
 	//	publicKeyChallengeFactor := getChallengeFactorWithPubKey(publicKeyList)
 	for i := 0; i < 10; i++ {
 		Pix, Piy, _ := PointUnmarshal(publicKeyList[i])
@@ -127,7 +127,7 @@ func TestMainTest(t *testing.T) {
 		verX, verY = Curve.Add(verX, verY, tempX, tempY)
 	}
 
-	verX, verY = Curve.Add(verX, verY, aggRx, aggRy)
+	verX, verY = Curve.Add(verX, verY, aggR.X, aggR.Y)
 
 	verX2, verY2 := Curve.ScalarBaseMult(aggSCalc.Bytes())
 
@@ -182,7 +182,7 @@ func TestSimpleSchnorrSignatureAggression(t *testing.T) {
 		Px, Py = Curve.Add(Px, Py, PiX, PiY)
 	}
 
-	e := getHash(Px, Py, Rx, message)
+	e := getHash(&Point{X: Px, Y: Py}, Rx, message)
 	s := new(big.Int).SetInt64(0)
 
 	for i, r0 := range privateRandomList {
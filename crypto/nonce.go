@@ -0,0 +1,57 @@
+package crypto
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"io"
+	"math/big"
+
+	"github.com/pkg/errors"
+)
+
+// A reused or biased r leaks the private key through s = r + e*x. DeriveNonce
+// hashes the secret key, the message and the aggregate key together with
+// fresh randomness, so the nonce stays unpredictable even if the random
+// source is weak, and distinct for distinct messages even if the random
+// source repeats. Signing derives its nonces this way unless the caller
+// passes one through WithNonce or SignWithNonce, such a nonce is the
+// caller's responsibility.
+
+var nonceRand io.Reader = rand.Reader
+
+// DeriveNonce returns a nonce in [1, N) for signing message with privKey under aggKey,
+// message may be nil if it is not known yet
+func DeriveNonce(privKey *big.Int, message, aggKey []byte) (*big.Int, error) {
+	return deriveNonce(privKey, message, aggKey, 0)
+}
+
+// the counter separates several nonces derived for one signing, e.g. the two of MuSig2
+func deriveNonce(privKey *big.Int, message, aggKey []byte, counter byte) (*big.Int, error) {
+	if err := ValidateSecretScalar(privKey); err != nil {
+		return nil, errors.Wrap(err, "private key")
+	}
+
+	randBytes := make([]byte, 32)
+	for {
+		if _, err := io.ReadFull(nonceRand, randBytes); err != nil {
+			return nil, errors.Wrap(err, "read nonce randomness")
+		}
+		hashed := taggedHash("musig-go/nonce",
+			randBytes,
+			scalarBytes(privKey),
+			lengthPrefixed(aggKey),
+			lengthPrefixed(message),
+			[]byte{counter})
+		k := new(big.Int).SetBytes(hashed[:])
+		k.Mod(k, Curve.N)
+		if k.Sign() != 0 {
+			return k, nil
+		}
+	}
+}
+
+func lengthPrefixed(b []byte) []byte {
+	ret := make([]byte, 8, 8+len(b))
+	binary.BigEndian.PutUint64(ret, uint64(len(b)))
+	return append(ret, b...)
+}
@@ -0,0 +1,75 @@
+package crypto
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+)
+
+func TestDeriveNonce(t *testing.T) {
+	Px, Py, pk := GenerateKeyPair()
+	aggKey := PointMarshal(Px, Py)
+
+	r1, err := DeriveNonce(pk, []byte("message"), aggKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r2, err := DeriveNonce(pk, []byte("message"), aggKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r1.Cmp(r2) == 0 {
+		t.Error("fresh randomness should give a fresh nonce")
+	}
+	if r1.Sign() <= 0 || r1.Cmp(Curve.N) >= 0 {
+		t.Error("nonce out of range")
+	}
+
+	if _, err := DeriveNonce(new(big.Int), []byte("message"), aggKey); err == nil {
+		t.Error("zero private key should be rejected")
+	}
+}
+
+func TestDeriveNonceBrokenRandomness(t *testing.T) {
+	saved := nonceRand
+	defer func() { nonceRand = saved }()
+	nonceRand = bytes.NewReader(make([]byte, 1024))
+
+	_, _, pk := GenerateKeyPair()
+	r1, err := DeriveNonce(pk, []byte("message 1"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r2, err := DeriveNonce(pk, []byte("message 2"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r1.Cmp(r2) == 0 {
+		t.Error("different messages must not share a nonce even with repeated randomness")
+	}
+}
+
+func TestSessionWithNonce(t *testing.T) {
+	Px, Py, pk := GenerateKeyPair()
+	key, err := AggregateKeys([][]byte{PointMarshal(Px, Py)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	Rx, Ry, r := GenerateKeyPair()
+
+	session, err := NewSession(key, pk, []byte("message"), WithNonce(r))
+	if err != nil {
+		t.Fatal(err)
+	}
+	Ri, err := session.Reveal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(Ri, PointMarshal(Rx, Ry)) {
+		t.Error("session should use the nonce of the caller")
+	}
+
+	if _, err := NewSession(key, pk, []byte("message"), WithNonce(new(big.Int))); err == nil {
+		t.Error("zero nonce should be rejected")
+	}
+}
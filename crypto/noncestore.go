@@ -0,0 +1,99 @@
+package crypto
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// A secret nonce must sign exactly one message: from s = r + e*x and
+// s' = r + e'*x anyone computes x = (s - s')/(e - e'). The in-memory checks of
+// a session are lost with the process, a NonceStore remembers which nonces
+// released a partial signature. Nonces are recorded by their public points,
+// the ledger never holds a secret.
+
+// ErrNonceReused is returned when a nonce already released a partial signature
+var ErrNonceReused = errors.New("nonce already used")
+
+// NonceStore records consumed nonces
+type NonceStore interface {
+	// Consume marks the nonce with the public encoding id as used, it must
+	// fail with ErrNonceReused if it was consumed before, also by another
+	// caller at the same time
+	Consume(id []byte) error
+}
+
+// MemoryNonceStore is a NonceStore for the lifetime of the process
+type MemoryNonceStore struct {
+	mu       sync.Mutex
+	consumed map[string]bool
+}
+
+// NewMemoryNonceStore returns an empty MemoryNonceStore
+func NewMemoryNonceStore() *MemoryNonceStore {
+	return &MemoryNonceStore{consumed: make(map[string]bool)}
+}
+
+// Consume implements NonceStore
+func (m *MemoryNonceStore) Consume(id []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.consumed[string(id)] {
+		return ErrNonceReused
+	}
+	m.consumed[string(id)] = true
+	return nil
+}
+
+// FileNonceStore is a NonceStore that survives restarts, every consumed nonce
+// is an empty file in dir named after the hash of its id. Creating the file
+// with O_EXCL is atomic, so processes sharing dir can not both consume a nonce.
+type FileNonceStore struct {
+	dir string
+}
+
+// NewFileNonceStore opens the ledger in dir and creates dir if needed
+func NewFileNonceStore(dir string) (*FileNonceStore, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, errors.Wrap(err, "create nonce ledger")
+	}
+	return &FileNonceStore{dir: dir}, nil
+}
+
+// Consume implements NonceStore, the entry is synced to disk before it returns
+func (f *FileNonceStore) Consume(id []byte) error {
+	hashed := sha256.Sum256(id)
+	name := filepath.Join(f.dir, hex.EncodeToString(hashed[:]))
+	file, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if os.IsExist(err) {
+		return ErrNonceReused
+	}
+	if err != nil {
+		return errors.Wrap(err, "record nonce")
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return errors.Wrap(err, "record nonce")
+	}
+	if err := file.Close(); err != nil {
+		return errors.Wrap(err, "record nonce")
+	}
+	return syncDir(f.dir)
+}
+
+// the new directory entry is only durable once the directory is synced
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return errors.Wrap(err, "sync nonce ledger")
+	}
+	defer d.Close()
+	if err := d.Sync(); err != nil {
+		return errors.Wrap(err, "sync nonce ledger")
+	}
+	return nil
+}
@@ -0,0 +1,146 @@
+package crypto
+
+import (
+	"math/big"
+	"sync"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func testNonceStore(t *testing.T, store NonceStore) {
+	id := ScalarBaseMult(big.NewInt(7)).Bytes()
+
+	// only one of the concurrent callers may consume the nonce
+	var wg sync.WaitGroup
+	results := make(chan error, 16)
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results <- store.Consume(id)
+		}()
+	}
+	wg.Wait()
+	close(results)
+	consumed := 0
+	for err := range results {
+		if err == nil {
+			consumed++
+		} else if err != ErrNonceReused {
+			t.Fatal(err)
+		}
+	}
+	if consumed != 1 {
+		t.Errorf("nonce consumed %d times", consumed)
+	}
+	if err := store.Consume(ScalarBaseMult(big.NewInt(8)).Bytes()); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestMemoryNonceStore(t *testing.T) {
+	testNonceStore(t, NewMemoryNonceStore())
+}
+
+func TestFileNonceStore(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewFileNonceStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testNonceStore(t, store)
+
+	// a new store on the same directory is a restarted process
+	reopened, err := NewFileNonceStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := reopened.Consume(ScalarBaseMult(big.NewInt(7)).Bytes()); err != ErrNonceReused {
+		t.Errorf("reopened ledger forgot the nonce, got %v", err)
+	}
+}
+
+func TestSessionNonceStore(t *testing.T) {
+	store, err := NewFileNonceStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	message := []byte("msg for signing")
+	base := newTestSessions(t, 3, message)
+	key := base[0].AggregateKey()
+
+	// the same r on two messages, as after a restart with a restored nonce
+	newSessions := func(message []byte) []*Session {
+		sessions := make([]*Session, len(base))
+		for i, session := range base {
+			var err error
+			sessions[i], err = NewSession(key, session.privKey, message, WithNonce(session.r), WithNonceStore(store))
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		runCommitRound(t, sessions)
+		runRevealRound(t, sessions)
+		return sessions
+	}
+
+	first := newSessions(message)
+	runPartialSignRound(t, first)
+	if _, err := first[0].Signature(); err != nil {
+		t.Fatal(err)
+	}
+	// asking the same session again hands out the same partial signature
+	if _, err := first[1].PartialSign(); err != nil {
+		t.Error(err)
+	}
+
+	second := newSessions([]byte("another message"))
+	if _, err := second[0].PartialSign(); !errors.Is(err, ErrNonceReused) {
+		t.Errorf("second signing with the same nonce, got %v", err)
+	}
+}
+
+func TestMusig2SessionNonceStore(t *testing.T) {
+	store := NewMemoryNonceStore()
+	Px, Py, pk := GenerateKeyPair()
+	Qx, Qy, _ := GenerateKeyPair()
+	key, err := AggregateKeys([][]byte{PointMarshal(Px, Py), PointMarshal(Qx, Qy)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	nonce, err := GenerateMusig2Nonce(pk, key.Bytes(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := GenerateMusig2Nonce(pk, key.Bytes(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	restored := *nonce
+
+	for i, message := range [][]byte{[]byte("first"), []byte("second")} {
+		n := nonce
+		if i > 0 {
+			n = &restored
+		}
+		session, err := NewMusig2Session(key, pk, n, message, WithNonceStore(store))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := session.AddNonce(1-session.Index(), other.R1, other.R2); err != nil {
+			t.Fatal(err)
+		}
+		_, err = session.PartialSign()
+		if i == 0 && err != nil {
+			t.Fatal(err)
+		}
+		if i > 0 && err != ErrNonceReused {
+			t.Errorf("restored nonce signed again, got %v", err)
+		}
+	}
+
+	if _, err := NewMusig2Session(key, pk, other, nil, WithNonce(big.NewInt(1))); err == nil {
+		t.Error("WithNonce should be rejected by MuSig2 sessions")
+	}
+}
@@ -0,0 +1,140 @@
+package crypto
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/pkg/errors"
+)
+
+// VerifyPartial checks s_i*G == R_i + e*a_i*P_i, R_i must already be negated
+// when the aggregate nonce was, for a tweaked key ai is gacc*a_i
+func VerifyPartial(si *big.Int, Ri, Pi []byte, ai, e *big.Int) (bool, error) {
+	if err := ValidateScalar(si); err != nil {
+		return false, errors.Wrap(err, "partial signature")
+	}
+	R, err := pointUnmarshal(Ri)
+	if err != nil {
+		return false, err
+	}
+	P, err := pointUnmarshal(Pi)
+	if err != nil {
+		return false, err
+	}
+
+	eai := new(big.Int).Mul(e, ai)
+	if !ScalarBaseMult(si).Equal(R.Add(P.ScalarMult(eai))) {
+		return false, errors.New("partial signature verification failed")
+	}
+	return true, nil
+}
+
+// PartialSignatureError reports the signers whose partial signature did not verify
+type PartialSignatureError struct {
+	Signers []int
+}
+
+func (e *PartialSignatureError) Error() string {
+	return fmt.Sprintf("invalid partial signature from signers %v", e.Signers)
+}
+
+// AggregatePartialSignatures checks every s_i against the R_i and the public
+// key of signer i before summing them, a *PartialSignatureError names every bad contributor
+func AggregatePartialSignatures(key *AggregateKey, Rs [][]byte, partials []*big.Int, message []byte) (*big.Int, error) {
+	aggR, err := getAggregatePoints(Rs)
+	if err != nil {
+		return nil, err
+	}
+	return verifyPartials(key, Rs, aggR, partials, message)
+}
+
+// Rs are the nonce points of the signers, they sum up to aggR before its Jacobi negation
+func verifyPartials(key *AggregateKey, Rs [][]byte, aggR *Point, partials []*big.Int, message []byte) (*big.Int, error) {
+	if len(Rs) != len(key.PubKeys) || len(partials) != len(key.PubKeys) {
+		return nil, errors.New("need one nonce and one partial signature per public key")
+	}
+
+	e := getHash(&key.Point, aggR.X, message)
+	negate := !aggR.hasSquareY()
+
+	var bad []int
+	for i, si := range partials {
+		if err := verifyPartialAt(key, i, Rs[i], negate, si, e); err != nil {
+			bad = append(bad, i)
+		}
+	}
+	if bad != nil {
+		return nil, &PartialSignatureError{Signers: bad}
+	}
+	aggS := aggreateMemberSignature(partials)
+	aggS.Add(aggS, key.tweakTerm(e))
+	return aggS.Mod(aggS, Curve.N), nil
+}
+
+func verifyPartialAt(key *AggregateKey, i int, Ri []byte, negate bool, si, e *big.Int) error {
+	if negate {
+		R, err := pointUnmarshal(Ri)
+		if err != nil {
+			return err
+		}
+		Ri = R.Neg().Bytes()
+	}
+	_, err := VerifyPartial(si, Ri, key.PubKeys[i], key.signerFactor(i), e)
+	return err
+}
+
+// VerifyCommitment checks the revealed R_i of a cosigner against its commitment Hash(R_i)
+func VerifyCommitment(commitment string, Ri []byte) (bool, error) {
+	R, err := pointUnmarshal(Ri)
+	if err != nil {
+		return false, errors.Wrap(err, "reveal")
+	}
+	return verifyHashRi(R, commitment)
+}
+
+// CombinePartialSignatures is AggregatePartialSignatures followed by the
+// check of the resulting signature Rx||s against key
+func CombinePartialSignatures(key *AggregateKey, Rs [][]byte, partials []*big.Int, message []byte) ([64]byte, error) {
+	aggR, err := getAggregatePoints(Rs)
+	if err != nil {
+		return [64]byte{}, err
+	}
+	if aggR.IsInfinity() {
+		return [64]byte{}, errors.New("aggregate nonce is the point at infinity")
+	}
+	aggS, err := verifyPartials(key, Rs, aggR, partials, message)
+	if err != nil {
+		return [64]byte{}, err
+	}
+	if _, err := verify(&key.Point, aggR.X, aggS, message); err != nil {
+		return [64]byte{}, errors.Wrap(err, "aggregate signature")
+	}
+	return signatureBytes(aggR.X, aggS), nil
+}
+
+// CombinePartialPreSignatures is CombinePartialSignatures for the partial
+// signatures of WithAdaptor sessions, it returns the pre-signature for the
+// PointMarshal encoded adaptor point T
+func CombinePartialPreSignatures(key *AggregateKey, Rs [][]byte, T []byte, partials []*big.Int, message []byte) (*AdaptorSignature, error) {
+	TP, err := pointUnmarshal(T)
+	if err != nil {
+		return nil, errors.Wrap(err, "adaptor point")
+	}
+	R, err := getAggregatePoints(Rs)
+	if err != nil {
+		return nil, err
+	}
+	aggR, _, err := adaptorNonce(R, TP)
+	if err != nil {
+		return nil, err
+	}
+	aggS, err := verifyPartials(key, Rs, aggR, partials, message)
+	if err != nil {
+		return nil, err
+	}
+	sig := &AdaptorSignature{R: R.Bytes(), S: aggS}
+	if _, err := adaptorVerify(&key.Point, T, message, sig); err != nil {
+		return nil, errors.Wrap(err, "aggregate pre-signature")
+	}
+	return sig, nil
+}
@@ -0,0 +1,85 @@
+package crypto
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func TestVerifyPartial(t *testing.T) {
+	sessions := newTestSessions(t, 4, []byte("partial"))
+	runCommitRound(t, sessions)
+	runRevealRound(t, sessions)
+
+	var Rs [][]byte
+	var partials []*big.Int
+	for _, session := range sessions {
+		Ri, _ := session.Reveal()
+		si, err := session.PartialSign()
+		if err != nil {
+			t.Fatal(err)
+		}
+		Rs = append(Rs, Ri)
+		partials = append(partials, si)
+	}
+	key := sessions[0].AggregateKey()
+
+	if _, err := AggregatePartialSignatures(key, Rs, partials, []byte("partial")); err != nil {
+		t.Fatal(err)
+	}
+
+	partials[1] = new(big.Int).Add(partials[1], big.NewInt(1))
+	partials[3] = new(big.Int).SetInt64(42)
+	_, err := AggregatePartialSignatures(key, Rs, partials, []byte("partial"))
+	var partialErr *PartialSignatureError
+	if !errors.As(err, &partialErr) {
+		t.Fatalf("expected PartialSignatureError, got %v", err)
+	}
+	if !reflect.DeepEqual(partialErr.Signers, []int{1, 3}) {
+		t.Errorf("bad signers %v, want [1 3]", partialErr.Signers)
+	}
+
+	err = sessions[0].AddPartialSignature(1, partials[1])
+	if !errors.As(err, &partialErr) || !reflect.DeepEqual(partialErr.Signers, []int{1}) {
+		t.Errorf("session should name signer 1, got %v", err)
+	}
+}
+
+func TestCombinePartialSignatures(t *testing.T) {
+	message := []byte("partial")
+	sessions := newTestSessions(t, 3, message)
+	runCommitRound(t, sessions)
+	runRevealRound(t, sessions)
+
+	var Rs [][]byte
+	var partials []*big.Int
+	for _, session := range sessions {
+		Ri, _ := session.Reveal()
+		if ok, err := VerifyCommitment(session.Commitment(), Ri); !ok {
+			t.Fatal(err)
+		}
+		si, err := session.PartialSign()
+		if err != nil {
+			t.Fatal(err)
+		}
+		Rs = append(Rs, Ri)
+		partials = append(partials, si)
+	}
+	if ok, _ := VerifyCommitment(sessions[0].Commitment(), Rs[1]); ok {
+		t.Error("reveal of signer 1 should not match the commitment of signer 0")
+	}
+
+	key := sessions[0].AggregateKey()
+	signature, err := CombinePartialSignatures(key, Rs, partials, message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := VerifyMsg(signature, message, key.X, key.Y); !ok {
+		t.Error(err)
+	}
+	if _, err := CombinePartialSignatures(key, Rs, partials, []byte("other")); err == nil {
+		t.Error("partial signatures of another message should not combine")
+	}
+}
@@ -0,0 +1,121 @@
+package crypto
+
+import (
+	"math/big"
+)
+
+// Point is an affine point of Curve. The point at infinity is (0, 0), the
+// same encoding btcec returns from Add and ScalarMult, so it can only come
+// out of arithmetic, never out of the decoders. Points are never modified
+// in place, every method returns a new one.
+type Point struct {
+	X, Y *big.Int
+}
+
+// Infinity returns the identity of the group
+func Infinity() *Point {
+	return &Point{X: new(big.Int), Y: new(big.Int)}
+}
+
+// NewPoint checks x, y with ValidatePoint
+func NewPoint(x, y *big.Int) (*Point, error) {
+	if err := ValidatePoint(x, y); err != nil {
+		return nil, err
+	}
+	return &Point{X: new(big.Int).Set(x), Y: new(big.Int).Set(y)}, nil
+}
+
+// ParsePoint reads a point in any encoding PointDecode accepts
+func ParsePoint(src []byte) (*Point, error) {
+	x, y, err := PointDecode(src)
+	if err != nil {
+		return nil, err
+	}
+	return &Point{X: x, Y: y}, nil
+}
+
+// ScalarBaseMult returns k*G
+func ScalarBaseMult(k *big.Int) *Point {
+	x, y := Curve.ScalarBaseMult(scalarBytes(new(big.Int).Mod(k, Curve.N)))
+	return &Point{X: x, Y: y}
+}
+
+// IsInfinity reports whether p is the identity, the zero Point counts as well
+func (p *Point) IsInfinity() bool {
+	return p == nil || p.X == nil || p.Y == nil || (p.X.Sign() == 0 && p.Y.Sign() == 0)
+}
+
+// Add returns p+q
+func (p *Point) Add(q *Point) *Point {
+	// Curve.Add hands back its other argument when one side is infinity, copy
+	// it so that the result never aliases an input
+	if p.IsInfinity() {
+		return q.copy()
+	}
+	if q.IsInfinity() {
+		return p.copy()
+	}
+	x, y := Curve.Add(p.X, p.Y, q.X, q.Y)
+	return &Point{X: x, Y: y}
+}
+
+// Neg returns -p
+func (p *Point) Neg() *Point {
+	if p.IsInfinity() {
+		return Infinity()
+	}
+	return &Point{X: new(big.Int).Set(p.X), Y: new(big.Int).Sub(Curve.P, p.Y)}
+}
+
+// ScalarMult returns k*p, k is taken mod N
+func (p *Point) ScalarMult(k *big.Int) *Point {
+	if p.IsInfinity() {
+		return Infinity()
+	}
+	x, y := Curve.ScalarMult(p.X, p.Y, scalarBytes(new(big.Int).Mod(k, Curve.N)))
+	return &Point{X: x, Y: y}
+}
+
+// Equal reports whether p and q are the same point
+func (p *Point) Equal(q *Point) bool {
+	if p.IsInfinity() || q.IsInfinity() {
+		return p.IsInfinity() && q.IsInfinity()
+	}
+	return p.X.Cmp(q.X) == 0 && p.Y.Cmp(q.Y) == 0
+}
+
+// Bytes is the 64-byte PointMarshal encoding, all zero for infinity
+func (p *Point) Bytes() []byte {
+	if p.IsInfinity() {
+		return make([]byte, 64)
+	}
+	return PointMarshal(p.X, p.Y)
+}
+
+// Compressed is the 33-byte SEC1 encoding, the single byte 0x00 for infinity
+func (p *Point) Compressed() []byte {
+	if p.IsInfinity() {
+		return []byte{0x00}
+	}
+	return PointMarshalCompressed(p.X, p.Y)
+}
+
+// Uncompressed is the 65-byte SEC1 encoding, the single byte 0x00 for infinity
+func (p *Point) Uncompressed() []byte {
+	if p.IsInfinity() {
+		return []byte{0x00}
+	}
+	return PointMarshalUncompressed(p.X, p.Y)
+}
+
+// hasSquareY is the Jacobi rule of Sign and Verify, infinity has none
+func (p *Point) hasSquareY() bool {
+	return !p.IsInfinity() && big.Jacobi(p.Y, Curve.P) == 1
+}
+
+func (p *Point) copy() *Point {
+	if p.IsInfinity() {
+		return Infinity()
+	}
+	return &Point{X: new(big.Int).Set(p.X), Y: new(big.Int).Set(p.Y)}
+}
@@ -0,0 +1,82 @@
+package crypto
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+)
+
+func TestPointArithmetic(t *testing.T) {
+	_, _, a := GenerateKeyPair()
+	_, _, b := GenerateKeyPair()
+	A, B := ScalarBaseMult(a), ScalarBaseMult(b)
+
+	sum := new(big.Int).Add(a, b)
+	if !A.Add(B).Equal(ScalarBaseMult(sum)) {
+		t.Error("aG + bG != (a+b)G")
+	}
+	if !A.ScalarMult(b).Equal(B.ScalarMult(a)) {
+		t.Error("b*(aG) != a*(bG)")
+	}
+	if !A.Neg().Equal(ScalarBaseMult(new(big.Int).Sub(Curve.N, a))) {
+		t.Error("-(aG) != (N-a)G")
+	}
+	if !A.Add(A).Equal(A.ScalarMult(big.NewInt(2))) {
+		t.Error("aG + aG != 2aG")
+	}
+}
+
+func TestPointInfinity(t *testing.T) {
+	_, _, a := GenerateKeyPair()
+	A := ScalarBaseMult(a)
+
+	if !A.Add(A.Neg()).IsInfinity() {
+		t.Error("P + (-P) should be infinity")
+	}
+	if !A.ScalarMult(Curve.N).IsInfinity() || !A.ScalarMult(new(big.Int)).IsInfinity() {
+		t.Error("N*P and 0*P should be infinity")
+	}
+	if !Infinity().Neg().IsInfinity() || !Infinity().ScalarMult(a).IsInfinity() {
+		t.Error("infinity should stay infinity")
+	}
+	if !(&Point{}).IsInfinity() || !(&Point{}).Equal(Infinity()) {
+		t.Error("the zero Point should be infinity")
+	}
+	if A.Equal(Infinity()) || Infinity().Equal(A) {
+		t.Error("P should not equal infinity")
+	}
+
+	// the identity must not hand back its argument
+	sum := Infinity().Add(A)
+	if !sum.Equal(A) {
+		t.Fatal("infinity + P != P")
+	}
+	sum.Y.Add(sum.Y, big.NewInt(1))
+	if sum.Equal(A) {
+		t.Error("infinity + P aliases P")
+	}
+}
+
+func TestPointEncodingMethods(t *testing.T) {
+	_, _, a := GenerateKeyPair()
+	A := ScalarBaseMult(a)
+
+	for _, encoded := range [][]byte{A.Bytes(), A.Compressed(), A.Uncompressed()} {
+		P, err := ParsePoint(encoded)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !P.Equal(A) {
+			t.Errorf("point changed after decoding %d bytes", len(encoded))
+		}
+	}
+	if !bytes.Equal(Infinity().Bytes(), make([]byte, 64)) {
+		t.Error("infinity should encode as 64 zero bytes")
+	}
+	if _, err := ParsePoint(Infinity().Compressed()); err == nil {
+		t.Error("infinity should not decode")
+	}
+	if _, err := NewPoint(A.X, new(big.Int).Add(A.Y, big.NewInt(1))); err == nil {
+		t.Error("off-curve point should be rejected")
+	}
+}
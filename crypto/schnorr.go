@@ -2,8 +2,6 @@ package crypto
 
 // 模仿自https://github.com/hbakhtiyor/schnorr/blob/master/schnorr.go
 import (
-	"crypto/ecdsa"
-	"crypto/rand"
 	"crypto/sha256"
 	"errors"
 	"fmt"
@@ -16,10 +14,12 @@ var (
 	Curve = btcec.S256()
 )
 
+// GenerateKeyPair is the raw form of NewPrivateKey
 func GenerateKeyPair() (Px, Py, pk *big.Int) {
-	key, err := ecdsa.GenerateKey(Curve, rand.Reader)
+	key, err := NewPrivateKey()
 	if err != nil {
 		fmt.Println(err)
+		return nil, nil, nil
 	}
 
 	return key.X, key.Y, key.D
@@ -46,25 +46,33 @@ func getJacobiResult(Ry, r *big.Int) *big.Int {
 }
 
 func PointUnmarshal(src []byte) (Px, Py *big.Int, err error) {
+	P, err := pointUnmarshal(src)
+	if err != nil {
+		return nil, nil, err
+	}
+	return P.X, P.Y, nil
+}
+
+func pointUnmarshal(src []byte) (*Point, error) {
+	if len(src) != 64 {
+		return nil,
+			errors.New(fmt.Sprintf("Point Unmarshal Error because of length, with len = %d", len(src)))
+	}
 	bPx := src[:32]
 	bPy := src[32:]
 
-	if len(bPx) != 32 || len(bPy) != 32 {
-		return nil, nil,
-			errors.New(fmt.Sprintf("Point Unmarshal Error because of length, with bPx = %d, bPy = %d",
-				len(bPx), len(bPy)))
-
+	Px := big.NewInt(0).SetBytes(bPx)
+	Py := big.NewInt(0).SetBytes(bPy)
+	if err := ValidatePoint(Px, Py); err != nil {
+		return nil, err
 	}
 
-	Px = big.NewInt(0).SetBytes(bPx)
-	Py = big.NewInt(0).SetBytes(bPy)
-
-	return Px, Py, nil
+	return &Point{X: Px, Y: Py}, nil
 }
 
 //H(P, Rx, m)
-func getHash(Px, Py, Rx *big.Int, message []byte) *big.Int {
-	payload := append(Px.Bytes(), Py.Bytes()...)
+func getHash(P *Point, Rx *big.Int, message []byte) *big.Int {
+	payload := append(P.X.Bytes(), P.Y.Bytes()...)
 	payload = append(payload, Rx.Bytes()...)
 	payload = append(payload, message...)
 	hashed := sha256.Sum256(payload)
@@ -72,44 +80,70 @@ func getHash(Px, Py, Rx *big.Int, message []byte) *big.Int {
 	return i.Mod(i, Curve.N)
 }
 
-// s = r+H(P, Rx, m)* pk
+// SignMsg is s = r+H(P, Rx, m)* pk, r is derived by DeriveNonce
+func SignMsg(pk *big.Int, message []byte) ([64]byte, error) {
+	r, err := DeriveNonce(pk, message, ScalarBaseMult(pk).Bytes())
+	if err != nil {
+		return [64]byte{}, err
+	}
+	return SignWithNonce(pk, r, message)
+}
+
+// Sign signs with the caller's nonce r.
+//
+// Deprecated: reusing or biasing r leaks pk, use SignMsg which derives r.
 func Sign(pk, r *big.Int, message []byte) ([64]byte, error) {
+	return SignWithNonce(pk, r, message)
+}
+
+// SignWithNonce signs with the caller's nonce r, it is only meant for tests,
+// reusing r for two messages leaks pk
+func SignWithNonce(pk, r *big.Int, message []byte) ([64]byte, error) {
 
-	Rx, Ry := Curve.ScalarBaseMult(r.Bytes())
-	r0 := getJacobiResult(Ry, r)
-	Px, Py := Curve.ScalarBaseMult(pk.Bytes())
-	hashedNum := getHash(Px, Py, Rx, message)
+	R := ScalarBaseMult(r)
+	r0 := getJacobiResult(R.Y, new(big.Int).Set(r))
+	hashedNum := getHash(ScalarBaseMult(pk), R.X, message)
 
 	hashedNum.Mul(hashedNum, pk)
 	r0.Add(r0, hashedNum)
 	r0.Mod(r0, Curve.N)
 
-	sig := [64]byte{}
-	copy(sig[:32], Rx.Bytes())
-	copy(sig[32:], r0.Bytes())
-	return sig, nil
+	return signatureBytes(R.X, r0), nil
+}
+
+//Rx||s, each left padded to 32 bytes
+func signatureBytes(Rx, s *big.Int) (sig [64]byte) {
+	bRx, bs := Rx.Bytes(), s.Bytes()
+	copy(sig[32-len(bRx):32], bRx)
+	copy(sig[64-len(bs):], bs)
+	return sig
 }
 
 //s*G = r*G + H*pk*G
 func Verify(Px, Py, Rx, s *big.Int, message []byte) (bool, error) {
-
-	if !Curve.IsOnCurve(Px, Py) {
+	P, err := NewPoint(Px, Py)
+	if err != nil {
 		return false, errors.New("signature verification failed, Public Key error")
 	}
-	hashedNum := getHash(Px, Py, Rx, message)
-
-	sGx, sGy := Curve.ScalarBaseMult(s.Bytes())
+	return verify(P, Rx, s, message)
+}
 
-	ePx, ePy := Curve.ScalarMult(Px, Py, hashedNum.Bytes())
-	ePy.Sub(Curve.P, ePy)
+func verify(P *Point, Rx, s *big.Int, message []byte) (bool, error) {
+	if ValidateFieldElement(Rx) != nil {
+		return false, errors.New("signature verification failed, Rx out of range")
+	}
+	if ValidateScalar(s) != nil {
+		return false, errors.New("signature verification failed, s out of range")
+	}
+	hashedNum := getHash(P, Rx, message)
 
-	RxCalc, RyCalc := Curve.Add(sGx, sGy, ePx, ePy)
+	RCalc := ScalarBaseMult(s).Add(P.ScalarMult(hashedNum).Neg())
 
-	if RxCalc.Sign() == 0 && RyCalc.Sign() == 0 {
+	if RCalc.IsInfinity() {
 		return false, errors.New("signature verification failed, get zero Rx and Ry")
-	} else if big.Jacobi(RyCalc, Curve.P) != 1 {
+	} else if !RCalc.hasSquareY() {
 		return false, errors.New("signature verification failed, Jacobi verification fail")
-	} else if RxCalc.Cmp(Rx) != 0 {
+	} else if RCalc.X.Cmp(Rx) != 0 {
 		return false, errors.New("signature verification failed, Rx verification fail")
 	}
 
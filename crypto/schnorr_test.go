@@ -25,21 +25,25 @@ func TestSignature(t *testing.T) {
 	Rx, _, r := GenerateKeyPair()
 
 	msg := []byte("fuckyoufuckyoufuccccc")
-	signature, _ := Sign(pk, r, msg)
+	signature, _ := SignWithNonce(pk, r, msg)
 
 	s := new(big.Int).SetBytes(signature[32:])
 	_, err := Verify(Px, Py, Rx, s, msg)
 	if err != nil {
 		t.Error(err)
 	}
+
+	// the deprecated form still signs with the caller's nonce
+	if old, _ := Sign(pk, r, msg); old != signature {
+		t.Error("Sign and SignWithNonce differ")
+	}
 }
 
 func TestVerify(t *testing.T) {
 	Px, Py, pk := GenerateKeyPair()
-	_, _, r := GenerateKeyPair()
 
 	msg := []byte("jy i love")
-	signature, err := Sign(pk, r, msg)
+	signature, err := SignMsg(pk, msg)
 	if err != nil {
 		t.Error(err)
 	}
@@ -52,10 +56,9 @@ func TestVerify(t *testing.T) {
 
 func TestVerifyFault(t *testing.T) {
 	Px, Py, pk := GenerateKeyPair()
-	_, _, r := GenerateKeyPair()
 
 	msg := []byte("jy i love")
-	signature, err := Sign(pk, r, msg)
+	signature, err := SignMsg(pk, msg)
 	if err != nil {
 		t.Error(err)
 	}
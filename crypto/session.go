@@ -0,0 +1,355 @@
+package crypto
+
+import (
+	"math/big"
+
+	"github.com/pkg/errors"
+)
+
+// rounds of a signing session, a session only moves to the next round
+// once the data of every cosigner for the current round has arrived
+const (
+	roundCommit = iota
+	roundReveal
+	roundPartialSign
+	roundAggregate
+)
+
+// Session drives one signer through commit -> reveal -> partial-sign -> aggregate
+type Session struct {
+	index   int
+	privKey *big.Int
+	message []byte
+	key     *AggregateKey
+
+	r *big.Int
+
+	round       int
+	commitments []string
+	reveals     [][]byte
+	partials    []*big.Int
+
+	// aggR is R+T when the session signs for an adaptor point T
+	aggR    *Point
+	e       *big.Int
+	adaptor *Point
+
+	nonceStore NonceStore
+	// id is the SessionID of the first message the session signed
+	id *SessionID
+}
+
+type sessionOptions struct {
+	nonce      *big.Int
+	adaptor    []byte
+	nonceStore NonceStore
+	// id is the SessionID of the first message the session signed
+	id *SessionID
+}
+
+// SessionOption changes the defaults of NewSession
+type SessionOption func(*sessionOptions)
+
+// WithNonce makes the session sign with the caller's nonce r instead of one
+// from DeriveNonce, it is meant for tests and for restoring a nonce saved
+// earlier, which must then be guarded with WithNonceStore
+func WithNonce(r *big.Int) SessionOption {
+	return func(o *sessionOptions) {
+		o.nonce = r
+	}
+}
+
+// WithAdaptor makes the session produce an adaptor signature for the
+// PointMarshal encoded point T, it is completed with the secret of T
+func WithAdaptor(T []byte) SessionOption {
+	return func(o *sessionOptions) {
+		o.adaptor = T
+	}
+}
+
+// WithNonceStore makes the session record its nonce in store before the
+// partial signature is released, a nonce store refuses to sign with twice
+func WithNonceStore(store NonceStore) SessionOption {
+	return func(o *sessionOptions) {
+		o.nonceStore = store
+	}
+}
+
+// NewSession starts a signing session for the owner of privKey, whose public key must be part of key
+func NewSession(key *AggregateKey, privKey *big.Int, message []byte, opts ...SessionOption) (*Session, error) {
+	if key == nil || len(key.PubKeys) == 0 {
+		return nil, errors.New("no public keys for session")
+	}
+	if err := ValidateSecretScalar(privKey); err != nil {
+		return nil, errors.Wrap(err, "private key")
+	}
+
+	index := key.Index(ScalarBaseMult(privKey).Bytes())
+	if index < 0 {
+		return nil, errors.New("public key of signer not in public key list")
+	}
+
+	var options sessionOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+	r := options.nonce
+	if r == nil {
+		var err error
+		r, err = DeriveNonce(privKey, message, key.Bytes())
+		if err != nil {
+			return nil, err
+		}
+	} else if err := ValidateSecretScalar(r); err != nil {
+		return nil, errors.Wrap(err, "nonce")
+	}
+	var adaptor *Point
+	if options.adaptor != nil {
+		var err error
+		if adaptor, err = pointUnmarshal(options.adaptor); err != nil {
+			return nil, errors.Wrap(err, "adaptor point")
+		}
+	}
+
+	R := ScalarBaseMult(r)
+	commitment, err := getHashRi(R)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Session{
+		index:       index,
+		privKey:     privKey,
+		message:     message,
+		key:         key,
+		r:           new(big.Int).Set(r),
+		round:       roundCommit,
+		commitments: make([]string, len(key.PubKeys)),
+		reveals:     make([][]byte, len(key.PubKeys)),
+		partials:    make([]*big.Int, len(key.PubKeys)),
+		adaptor:     adaptor,
+		nonceStore:  options.nonceStore,
+	}
+	s.commitments[index] = commitment
+	s.reveals[index] = R.Bytes()
+	if err := s.advance(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Index of the signer in the public key list
+func (s *Session) Index() int {
+	return s.index
+}
+
+// AggregateKey is the key the final signature verifies against
+func (s *Session) AggregateKey() *AggregateKey {
+	return s.key
+}
+
+// Message is what the session signs
+func (s *Session) Message() []byte {
+	return s.message
+}
+
+// Adaptor is the PointMarshal encoded adaptor point of a WithAdaptor session, nil otherwise
+func (s *Session) Adaptor() []byte {
+	if s.adaptor == nil {
+		return nil
+	}
+	return s.adaptor.Bytes()
+}
+
+// Commitment is Hash(R_i) of the signer, sent to the cosigners in round one
+func (s *Session) Commitment() string {
+	return s.commitments[s.index]
+}
+
+func checkCosigner(index, owner, n int) error {
+	if index < 0 || index >= n {
+		return errors.Errorf("signer index %d out of range", index)
+	}
+	if index == owner {
+		return errors.Errorf("signer index %d is the session owner", index)
+	}
+	return nil
+}
+
+// AddCommitment records Hash(R_i) of a cosigner
+func (s *Session) AddCommitment(index int, commitment string) error {
+	if s.round != roundCommit {
+		return errors.New("commitments are only accepted in the commit round")
+	}
+	if err := checkCosigner(index, s.index, len(s.key.PubKeys)); err != nil {
+		return err
+	}
+	if s.commitments[index] != "" {
+		return errors.Errorf("commitment of signer %d already received", index)
+	}
+	if err := validateCommitment(commitment); err != nil {
+		return errors.Wrapf(err, "commitment of signer %d", index)
+	}
+	s.commitments[index] = commitment
+	return s.advance()
+}
+
+// Reveal returns R_i of the signer, it is only released once every commitment has arrived
+func (s *Session) Reveal() ([]byte, error) {
+	if s.round < roundReveal {
+		return nil, errors.New("can not reveal before all commitments are received")
+	}
+	return s.reveals[s.index], nil
+}
+
+// AddReveal records R_i of a cosigner and checks it against the commitment
+func (s *Session) AddReveal(index int, Ri []byte) error {
+	if s.round != roundReveal {
+		return errors.New("reveals are only accepted in the reveal round")
+	}
+	if err := checkCosigner(index, s.index, len(s.key.PubKeys)); err != nil {
+		return err
+	}
+	if s.reveals[index] != nil {
+		return errors.Errorf("reveal of signer %d already received", index)
+	}
+	R, err := pointUnmarshal(Ri)
+	if err != nil {
+		return errors.Wrapf(err, "reveal of signer %d", index)
+	}
+	if _, err := verifyHashRi(R, s.commitments[index]); err != nil {
+		return errors.Wrapf(err, "reveal of signer %d", index)
+	}
+	s.reveals[index] = Ri
+	return s.advance()
+}
+
+// PartialSign returns s_i = r_i + H(X, Rx, m)*a_i*x_i once every R_i is known,
+// a_i also carries the sign flips of the x-only tweaks of the key
+func (s *Session) PartialSign() (*big.Int, error) {
+	if s.round < roundPartialSign {
+		return nil, errors.New("can not sign before all reveals are received")
+	}
+	if s.partials[s.index] != nil {
+		return new(big.Int).Set(s.partials[s.index]), nil
+	}
+	if s.nonceStore != nil {
+		if err := s.nonceStore.Consume(s.reveals[s.index]); err != nil {
+			return nil, err
+		}
+	}
+
+	pkChallengeFactor := new(big.Int).Mul(s.privKey, s.key.signerFactor(s.index))
+	si := generateMemberSignature(pkChallengeFactor, s.r, s.aggR, &s.key.Point, s.message)
+	s.partials[s.index] = si
+	if err := s.advance(); err != nil {
+		return nil, err
+	}
+	return new(big.Int).Set(si), nil
+}
+
+// AddPartialSignature records s_i of a cosigner
+func (s *Session) AddPartialSignature(index int, si *big.Int) error {
+	if s.round != roundPartialSign {
+		return errors.New("partial signatures are only accepted in the partial-sign round")
+	}
+	if err := checkCosigner(index, s.index, len(s.key.PubKeys)); err != nil {
+		return err
+	}
+	if s.partials[index] != nil {
+		return errors.Errorf("partial signature of signer %d already received", index)
+	}
+	negate := !s.aggR.hasSquareY()
+	if err := verifyPartialAt(s.key, index, s.reveals[index], negate, si, s.e); err != nil {
+		return &PartialSignatureError{Signers: []int{index}}
+	}
+	s.partials[index] = new(big.Int).Set(si)
+	return s.advance()
+}
+
+// advance moves the session on for as long as the data of the current round is complete
+func (s *Session) advance() error {
+	if s.round == roundCommit {
+		for _, c := range s.commitments {
+			if c == "" {
+				return nil
+			}
+		}
+		s.round = roundReveal
+	}
+	if s.round == roundReveal {
+		for _, R := range s.reveals {
+			if R == nil {
+				return nil
+			}
+		}
+		aggR, err := getAggregatePoints(s.reveals)
+		if err != nil {
+			return err
+		}
+		if s.adaptor != nil {
+			aggR = aggR.Add(s.adaptor)
+		}
+		if aggR.IsInfinity() {
+			return errors.New("aggregate nonce is the point at infinity")
+		}
+		s.aggR = aggR
+		s.e = getHash(&s.key.Point, aggR.X, s.message)
+		s.round = roundPartialSign
+	}
+	if s.round == roundPartialSign && allPartials(s.partials) {
+		s.round = roundAggregate
+	}
+	return nil
+}
+
+func allPartials(partials []*big.Int) bool {
+	for _, si := range partials {
+		if si == nil {
+			return false
+		}
+	}
+	return true
+}
+
+// Signature aggregates the partial signatures into Rx||s, it verifies against AggregateKey
+func (s *Session) Signature() ([64]byte, error) {
+	if s.round != roundAggregate {
+		return [64]byte{}, errors.New("can not aggregate before all partial signatures are received")
+	}
+	if s.adaptor != nil {
+		return [64]byte{}, errors.New("session signs for an adaptor point, use PreSignature")
+	}
+	aggS, err := verifyPartials(s.key, s.reveals, s.aggR, s.partials, s.message)
+	if err != nil {
+		return [64]byte{}, err
+	}
+	if _, err := verify(&s.key.Point, s.aggR.X, aggS, s.message); err != nil {
+		return [64]byte{}, errors.Wrap(err, "aggregate signature")
+	}
+	return signatureBytes(s.aggR.X, aggS), nil
+}
+
+// PreSignature aggregates the partial signatures of a WithAdaptor session
+// into an adaptor signature for AggregateKey
+func (s *Session) PreSignature() (*AdaptorSignature, error) {
+	if s.round != roundAggregate {
+		return nil, errors.New("can not aggregate before all partial signatures are received")
+	}
+	if s.adaptor == nil {
+		return nil, errors.New("session has no adaptor point")
+	}
+	aggS, err := verifyPartials(s.key, s.reveals, s.aggR, s.partials, s.message)
+	if err != nil {
+		return nil, err
+	}
+	R, err := getAggregatePoints(s.reveals)
+	if err != nil {
+		return nil, err
+	}
+	sig := &AdaptorSignature{R: R.Bytes(), S: aggS}
+	if _, err := adaptorVerify(&s.key.Point, s.adaptor.Bytes(), s.message, sig); err != nil {
+		return nil, errors.Wrap(err, "aggregate pre-signature")
+	}
+	return sig, nil
+}
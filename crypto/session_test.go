@@ -0,0 +1,147 @@
+package crypto
+
+import (
+	"math/big"
+	"testing"
+)
+
+func newTestSessions(t *testing.T, n int, message []byte) []*Session {
+	var publicKeyList [][]byte
+	var privateKeyList []*big.Int
+	for i := 0; i < n; i++ {
+		Px, Py, pk := GenerateKeyPair()
+		publicKeyList = append(publicKeyList, PointMarshal(Px, Py))
+		privateKeyList = append(privateKeyList, pk)
+	}
+
+	key, err := AggregateKeys(publicKeyList)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// sessions[i] is the signer of key.PubKeys[i]
+	sessions := make([]*Session, n)
+	for i := 0; i < n; i++ {
+		session, err := NewSession(key, privateKeyList[i], message)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if session.Index() != key.Index(publicKeyList[i]) {
+			t.Fatalf("session index %d, want %d", session.Index(), key.Index(publicKeyList[i]))
+		}
+		sessions[session.Index()] = session
+	}
+	return sessions
+}
+
+func runCommitRound(t *testing.T, sessions []*Session) {
+	for _, session := range sessions {
+		for _, other := range sessions {
+			if other == session {
+				continue
+			}
+			if err := session.AddCommitment(other.Index(), other.Commitment()); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+}
+
+func runRevealRound(t *testing.T, sessions []*Session) {
+	for _, session := range sessions {
+		for _, other := range sessions {
+			if other == session {
+				continue
+			}
+			Ri, err := other.Reveal()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := session.AddReveal(other.Index(), Ri); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+}
+
+func runPartialSignRound(t *testing.T, sessions []*Session) {
+	var partials []*big.Int
+	for _, session := range sessions {
+		si, err := session.PartialSign()
+		if err != nil {
+			t.Fatal(err)
+		}
+		partials = append(partials, si)
+	}
+	for _, session := range sessions {
+		for j, si := range partials {
+			if j == session.Index() {
+				continue
+			}
+			if err := session.AddPartialSignature(j, si); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+}
+
+func TestSession(t *testing.T) {
+	message := []byte("msg for signing")
+	sessions := newTestSessions(t, 5, message)
+
+	runCommitRound(t, sessions)
+	runRevealRound(t, sessions)
+	runPartialSignRound(t, sessions)
+
+	for _, session := range sessions {
+		signature, err := session.Signature()
+		if err != nil {
+			t.Fatal(err)
+		}
+		key := session.AggregateKey()
+		if ok, err := VerifyMsg(signature, message, key.X, key.Y); !ok {
+			t.Error(err)
+		}
+	}
+}
+
+func TestSessionRoundOrder(t *testing.T) {
+	sessions := newTestSessions(t, 3, []byte("round order"))
+	session := sessions[0]
+
+	if _, err := session.Reveal(); err == nil {
+		t.Error("reveal should wait for all commitments")
+	}
+	if _, err := session.PartialSign(); err == nil {
+		t.Error("partial sign should wait for all reveals")
+	}
+	if _, err := session.Signature(); err == nil {
+		t.Error("aggregation should wait for all partial signatures")
+	}
+
+	if err := session.AddCommitment(1, sessions[1].Commitment()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := session.Reveal(); err == nil {
+		t.Error("reveal should wait for the missing commitment")
+	}
+	if err := session.AddCommitment(1, sessions[1].Commitment()); err == nil {
+		t.Error("duplicated commitment should be rejected")
+	}
+	if err := session.AddCommitment(0, session.Commitment()); err == nil {
+		t.Error("commitment of the session owner should be rejected")
+	}
+}
+
+func TestSessionRevealMismatch(t *testing.T) {
+	sessions := newTestSessions(t, 3, []byte("reveal mismatch"))
+	runCommitRound(t, sessions)
+
+	R2, err := sessions[2].Reveal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sessions[0].AddReveal(1, R2); err == nil {
+		t.Error("reveal not matching the commitment should be rejected")
+	}
+}
@@ -0,0 +1,296 @@
+package crypto
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"math/big"
+
+	"github.com/pkg/errors"
+)
+
+// A saved session lets a signer that restarts in the middle of a signing
+// finish it. The state holds the public data of the session in JSON and the
+// secret nonce r encrypted with AES-256-GCM under a key derived from the
+// private key of the signer, which is needed to restore it anyway. The
+// associated data of the ciphertext covers everything else in the state, a
+// state edited to sign another message, for another adaptor point or with
+// other cosigner data does not decrypt. The state can still be rolled back to
+// an older copy, so what finally keeps r from signing twice is the nonce
+// store: only sessions with a NonceStore can be saved and a restored session
+// must be given the same one.
+
+const sessionStateVersion = 1
+
+var roundNames = []string{
+	roundCommit:      "commit",
+	roundReveal:      "reveal",
+	roundPartialSign: "partial_sign",
+	roundAggregate:   "aggregate",
+}
+
+type sessionState struct {
+	Version int `json:"version"`
+	// PubKeys in key order, the key is rebuilt with KeepKeyOrder
+	PubKeys [][]byte `json:"public_keys"`
+	Key     []byte   `json:"aggregate_key"`
+	// Gacc and Tacc are only set for a tweaked key
+	Gacc    []byte `json:"gacc,omitempty"`
+	Tacc    []byte `json:"tacc,omitempty"`
+	Index   int    `json:"index"`
+	Message []byte `json:"message"`
+	Adaptor []byte `json:"adaptor,omitempty"`
+	Round   string `json:"round"`
+	// SessionID is only set once the session signed a round message
+	SessionID []byte `json:"session_id,omitempty"`
+	// Commitments, Reveals and Partials are indexed by signer, empty for
+	// those that have not arrived
+	Commitments []string `json:"commitments"`
+	Reveals     [][]byte `json:"reveals"`
+	Partials    [][]byte `json:"partials"`
+	Nonce       []byte   `json:"encrypted_nonce"`
+}
+
+// associatedData binds the encrypted nonce to every other field of the state
+func (st *sessionState) associatedData() []byte {
+	parts := [][]byte{{byte(st.Version)},
+		uint32Bytes(st.Index),
+		lengthPrefixed(st.Key),
+		lengthPrefixed(st.Gacc),
+		lengthPrefixed(st.Tacc),
+		lengthPrefixed(st.Message),
+		lengthPrefixed(st.Adaptor),
+		lengthPrefixed([]byte(st.Round)),
+		lengthPrefixed(st.SessionID),
+		uint32Bytes(len(st.Commitments))}
+	for _, c := range st.Commitments {
+		parts = append(parts, lengthPrefixed([]byte(c)))
+	}
+	for _, list := range [][][]byte{st.PubKeys, st.Reveals, st.Partials} {
+		parts = append(parts, uint32Bytes(len(list)))
+		for _, b := range list {
+			parts = append(parts, lengthPrefixed(b))
+		}
+	}
+	hashed := taggedHash("musig-go/session-state", parts...)
+	return hashed[:]
+}
+
+func uint32Bytes(i int) []byte {
+	ret := make([]byte, 4)
+	binary.BigEndian.PutUint32(ret, uint32(i))
+	return ret
+}
+
+// AES-256-GCM with the key H(x) of the signer
+func stateCipher(privKey *big.Int) (cipher.AEAD, error) {
+	key := taggedHash("musig-go/session-state/key", scalarBytes(privKey))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// MarshalState saves the session for RestoreSession, the nonce is encrypted
+// with a key derived from the private key of the signer. Only sessions with a
+// NonceStore can be saved.
+func (s *Session) MarshalState() ([]byte, error) {
+	if s.nonceStore == nil {
+		return nil, errors.New("only sessions with a nonce store can be saved")
+	}
+	st := &sessionState{
+		Version:     sessionStateVersion,
+		PubKeys:     s.key.PubKeys,
+		Key:         s.key.Bytes(),
+		Index:       s.index,
+		Message:     s.message,
+		Round:       roundNames[s.round],
+		Commitments: s.commitments,
+		Reveals:     s.reveals,
+		Partials:    make([][]byte, len(s.partials)),
+	}
+	if s.key.gacc != nil || s.key.tacc != nil {
+		gacc, tacc := s.key.tweakAccumulators()
+		st.Gacc, st.Tacc = scalarBytes(gacc), scalarBytes(tacc)
+	}
+	if s.adaptor != nil {
+		st.Adaptor = s.adaptor.Bytes()
+	}
+	if s.id != nil {
+		st.SessionID = s.id[:]
+	}
+	for i, si := range s.partials {
+		if si != nil {
+			st.Partials[i] = scalarBytes(si)
+		}
+	}
+
+	aead, err := stateCipher(s.privKey)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, errors.Wrap(err, "read state nonce")
+	}
+	st.Nonce = aead.Seal(nonce, nonce, scalarBytes(s.r), st.associatedData())
+	return json.Marshal(st)
+}
+
+// RestoreSession continues a session saved with MarshalState for the owner
+// of privKey. It only takes WithNonceStore, which is required and must be the
+// store of the saved session: a partial signature that was already released
+// is returned again, a rolled back state can not release another one.
+func RestoreSession(state []byte, privKey *big.Int, opts ...SessionOption) (*Session, error) {
+	var options sessionOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+	if options.nonce != nil || options.adaptor != nil {
+		return nil, errors.New("restored sessions only take WithNonceStore")
+	}
+	if options.nonceStore == nil {
+		return nil, errors.New("restored sessions need WithNonceStore")
+	}
+	if err := ValidateSecretScalar(privKey); err != nil {
+		return nil, errors.Wrap(err, "private key")
+	}
+
+	var st sessionState
+	dec := json.NewDecoder(bytes.NewReader(state))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&st); err != nil {
+		return nil, errors.Wrap(err, "decode session state")
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, ErrTrailingData
+	}
+	if st.Version != sessionStateVersion {
+		return nil, errors.Errorf("unsupported session state version %d", st.Version)
+	}
+	n := len(st.PubKeys)
+	if len(st.Commitments) != n || len(st.Reveals) != n || len(st.Partials) != n {
+		return nil, errors.New("session state does not hold the data of every signer")
+	}
+
+	aead, err := stateCipher(privKey)
+	if err != nil {
+		return nil, err
+	}
+	if len(st.Nonce) < aead.NonceSize() {
+		return nil, errors.New("encrypted nonce too short")
+	}
+	plain, err := aead.Open(nil, st.Nonce[:aead.NonceSize()], st.Nonce[aead.NonceSize():], st.associatedData())
+	if err != nil {
+		return nil, errors.New("session state was changed or belongs to another private key")
+	}
+	r := new(big.Int).SetBytes(plain)
+
+	key, err := restoreKey(&st)
+	if err != nil {
+		return nil, err
+	}
+	sessionOpts := []SessionOption{WithNonce(r), WithNonceStore(options.nonceStore)}
+	if st.Adaptor != nil {
+		sessionOpts = append(sessionOpts, WithAdaptor(st.Adaptor))
+	}
+	s, err := NewSession(key, privKey, st.Message, sessionOpts...)
+	if err != nil {
+		return nil, err
+	}
+	if s.index != st.Index || s.Commitment() != st.Commitments[s.index] {
+		return nil, errors.New("session state belongs to another signer")
+	}
+	if err := s.replay(&st); err != nil {
+		return nil, errors.Wrap(err, "restore session")
+	}
+	if roundNames[s.round] != st.Round {
+		return nil, errors.Errorf("restored session is in the %s round, state says %s", roundNames[s.round], st.Round)
+	}
+	if st.SessionID != nil {
+		var id SessionID
+		if len(st.SessionID) != len(id) {
+			return nil, errors.New("invalid session id in session state")
+		}
+		copy(id[:], st.SessionID)
+		s.id = &id
+	}
+	return s, nil
+}
+
+// restoreKey rebuilds the aggregate key, with the tweaks Q = gacc*Q0 + tacc*G
+func restoreKey(st *sessionState) (*AggregateKey, error) {
+	key, err := AggregateKeys(st.PubKeys, KeepKeyOrder())
+	if err != nil {
+		return nil, err
+	}
+	if st.Gacc != nil || st.Tacc != nil {
+		gacc := new(big.Int).SetBytes(st.Gacc)
+		tacc := new(big.Int).SetBytes(st.Tacc)
+		if gacc.Sign() == 0 || gacc.Cmp(Curve.N) >= 0 || tacc.Cmp(Curve.N) >= 0 {
+			return nil, errors.New("invalid key tweak in session state")
+		}
+		Q := key.Point.ScalarMult(gacc).Add(ScalarBaseMult(tacc))
+		if Q.IsInfinity() {
+			return nil, errors.New("tweaked key is the point at infinity")
+		}
+		key = &AggregateKey{
+			Point:        *Q,
+			Coefficients: key.Coefficients,
+			PubKeys:      key.PubKeys,
+			gacc:         gacc,
+			tacc:         tacc,
+		}
+	}
+	if !bytes.Equal(key.Bytes(), st.Key) {
+		return nil, errors.New("public keys do not aggregate to the saved key")
+	}
+	return key, nil
+}
+
+// replay feeds the saved data of the cosigners through the checks of a live
+// session. The signer's own partial signature is taken over without
+// consuming the nonce again, it was consumed when it was made.
+func (s *Session) replay(st *sessionState) error {
+	for i, c := range st.Commitments {
+		if i != s.index && c != "" {
+			if err := s.AddCommitment(i, c); err != nil {
+				return err
+			}
+		}
+	}
+	for i, Ri := range st.Reveals {
+		if i != s.index && Ri != nil {
+			if err := s.AddReveal(i, Ri); err != nil {
+				return err
+			}
+		}
+	}
+	if !bytes.Equal(st.Reveals[s.index], s.reveals[s.index]) {
+		return errors.New("saved reveal does not match the nonce")
+	}
+	for i, b := range st.Partials {
+		if i != s.index && b != nil {
+			if err := s.AddPartialSignature(i, new(big.Int).SetBytes(b)); err != nil {
+				return err
+			}
+		}
+	}
+	if own := st.Partials[s.index]; own != nil {
+		if s.round < roundPartialSign {
+			return errors.New("partial signature saved before all reveals")
+		}
+		si := new(big.Int).SetBytes(own)
+		if err := verifyPartialAt(s.key, s.index, s.reveals[s.index], !s.aggR.hasSquareY(), si, s.e); err != nil {
+			return errors.Wrap(err, "saved partial signature")
+		}
+		s.partials[s.index] = si
+		return s.advance()
+	}
+	return nil
+}
@@ -0,0 +1,237 @@
+package crypto
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+)
+
+// newTestStoredSessions returns sessions that each record their nonce in their own store
+func newTestStoredSessions(t *testing.T, key *AggregateKey, privKeys []*big.Int, message []byte, opts ...SessionOption) ([]*Session, []NonceStore) {
+	sessions := make([]*Session, len(privKeys))
+	stores := make([]NonceStore, len(privKeys))
+	for _, pk := range privKeys {
+		store := NewMemoryNonceStore()
+		session, err := NewSession(key, pk, message, append(opts, WithNonceStore(store))...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sessions[session.Index()] = session
+		stores[session.Index()] = store
+	}
+	return sessions, stores
+}
+
+func newTestKeys(t *testing.T, n int) (*AggregateKey, []*big.Int) {
+	var pubkeys [][]byte
+	var privKeys []*big.Int
+	for i := 0; i < n; i++ {
+		Px, Py, pk := GenerateKeyPair()
+		pubkeys = append(pubkeys, PointMarshal(Px, Py))
+		privKeys = append(privKeys, pk)
+	}
+	key, err := AggregateKeys(pubkeys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key, privKeys
+}
+
+// restore saves sessions[0] and puts the restored session in its place
+func restore(t *testing.T, sessions []*Session, privKey *big.Int, store NonceStore) {
+	state, err := sessions[0].MarshalState()
+	if err != nil {
+		t.Fatal(err)
+	}
+	restored, err := RestoreSession(state, privKey, WithNonceStore(store))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restored.round != sessions[0].round {
+		t.Errorf("restored in round %s, saved in %s", roundNames[restored.round], roundNames[sessions[0].round])
+	}
+	sessions[0] = restored
+}
+
+func TestRestoreSession(t *testing.T) {
+	message := []byte("msg for signing")
+	for _, tweak := range []bool{false, true} {
+		key, privKeys := newTestKeys(t, 3)
+		if tweak {
+			var err error
+			if key, err = key.XOnlyTweak(big.NewInt(7)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		sessions, stores := newTestStoredSessions(t, key, privKeys, message)
+		privKey := sessions[0].privKey
+
+		// a restart in every round, the partial signature is released once
+		restore(t, sessions, privKey, stores[0])
+		runCommitRound(t, sessions)
+		restore(t, sessions, privKey, stores[0])
+		runRevealRound(t, sessions)
+		restore(t, sessions, privKey, stores[0])
+		si, err := sessions[0].PartialSign()
+		if err != nil {
+			t.Fatal(err)
+		}
+		restore(t, sessions, privKey, stores[0])
+		again, err := sessions[0].PartialSign()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if again.Cmp(si) != 0 {
+			t.Error("restored session made another partial signature")
+		}
+
+		runPartialSignRound(t, sessions)
+		restore(t, sessions, privKey, stores[0])
+		signature, err := sessions[0].Signature()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ok, err := VerifyMsg(signature, message, key.X, key.Y); !ok {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestRestoreSessionAdaptor(t *testing.T) {
+	message := []byte("msg for signing")
+	key, privKeys := newTestKeys(t, 2)
+	_, _, secret := GenerateKeyPair()
+	T := ScalarBaseMult(secret).Bytes()
+	sessions, stores := newTestStoredSessions(t, key, privKeys, message, WithAdaptor(T))
+
+	runCommitRound(t, sessions)
+	runRevealRound(t, sessions)
+	restore(t, sessions, sessions[0].privKey, stores[0])
+	runPartialSignRound(t, sessions)
+	pre, err := sessions[0].PreSignature()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := AdaptorVerify(key.X, key.Y, T, message, pre); !ok {
+		t.Fatal(err)
+	}
+}
+
+func TestRestoreSessionRollback(t *testing.T) {
+	message := []byte("msg for signing")
+	key, privKeys := newTestKeys(t, 2)
+	sessions, stores := newTestStoredSessions(t, key, privKeys, message)
+	runCommitRound(t, sessions)
+	runRevealRound(t, sessions)
+	state, err := sessions[0].MarshalState()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sessions[0].PartialSign(); err != nil {
+		t.Fatal(err)
+	}
+
+	// the partial signature was released after the state was saved
+	restored, err := RestoreSession(state, sessions[0].privKey, WithNonceStore(stores[0]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := restored.PartialSign(); err != ErrNonceReused {
+		t.Errorf("rolled back session signed again, got %v", err)
+	}
+}
+
+func TestRestoreSessionID(t *testing.T) {
+	message := []byte("msg for signing")
+	key, privKeys := newTestKeys(t, 2)
+	sessions, stores := newTestStoredSessions(t, key, privKeys, message)
+	id, _ := NewSessionID()
+	m, _ := NewCommitmentMessage(id, 0, sessions[0].Commitment())
+	if err := sessions[0].SignMessage(m); err != nil {
+		t.Fatal(err)
+	}
+
+	restore(t, sessions, sessions[0].privKey, stores[0])
+	otherID, _ := NewSessionID()
+	m, _ = NewCommitmentMessage(otherID, 0, sessions[0].Commitment())
+	if err := sessions[0].SignMessage(m); err == nil {
+		t.Error("restored session signed under another session id")
+	}
+	m, _ = NewCommitmentMessage(id, 0, sessions[0].Commitment())
+	if err := sessions[0].SignMessage(m); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestRestoreSessionRejects(t *testing.T) {
+	message := []byte("msg for signing")
+	key, privKeys := newTestKeys(t, 3)
+	sessions, stores := newTestStoredSessions(t, key, privKeys, message)
+	runCommitRound(t, sessions)
+	privKey := sessions[0].privKey
+	state, err := sessions[0].MarshalState()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	unstored, err := NewSession(key, privKey, message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := unstored.MarshalState(); err == nil {
+		t.Error("session without a nonce store saved")
+	}
+	if _, err := RestoreSession(state, privKey); err == nil {
+		t.Error("session restored without a nonce store")
+	}
+	if _, err := RestoreSession(state, privKey, WithNonceStore(stores[0]), WithNonce(big.NewInt(1))); err == nil {
+		t.Error("session restored with another nonce")
+	}
+	if _, err := RestoreSession(state, sessions[1].privKey, WithNonceStore(stores[0])); err == nil {
+		t.Error("session restored with the key of another signer")
+	}
+	if _, err := RestoreSession(append(state, '{', '}'), privKey, WithNonceStore(stores[0])); err != ErrTrailingData {
+		t.Errorf("trailing data, got %v", err)
+	}
+
+	T := ScalarBaseMult(big.NewInt(1)).Bytes()
+	for name, change := range map[string]func(st *sessionState){
+		"message":    func(st *sessionState) { st.Message = []byte("other msg") },
+		"adaptor":    func(st *sessionState) { st.Adaptor = T },
+		"commitment": func(st *sessionState) { st.Commitments[1] = st.Commitments[2] },
+		"round":      func(st *sessionState) { st.Round = roundNames[roundCommit] },
+		"version":    func(st *sessionState) { st.Version = 2 },
+		"nonce":      func(st *sessionState) { st.Nonce[len(st.Nonce)-1] ^= 1 },
+	} {
+		var st sessionState
+		if err := json.Unmarshal(state, &st); err != nil {
+			t.Fatal(err)
+		}
+		change(&st)
+		changed, err := json.Marshal(&st)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := RestoreSession(changed, privKey, WithNonceStore(stores[0])); err == nil {
+			t.Errorf("state with another %s restored", name)
+		}
+	}
+
+	// a state that decrypts must also hold together
+	var st sessionState
+	if err := json.Unmarshal(state, &st); err != nil {
+		t.Fatal(err)
+	}
+	st.Reveals[1] = ScalarBaseMult(big.NewInt(1)).Bytes()
+	st.Round = roundNames[roundPartialSign]
+	aead, err := stateCipher(privKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	nonce := st.Nonce[:aead.NonceSize()]
+	st.Nonce = aead.Seal(nonce, nonce, scalarBytes(sessions[0].r), st.associatedData())
+	forged, _ := json.Marshal(&st)
+	if _, err := RestoreSession(forged, privKey, WithNonceStore(stores[0])); err == nil {
+		t.Error("reveal that does not match the commitment restored")
+	}
+}
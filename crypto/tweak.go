@@ -0,0 +1,91 @@
+package crypto
+
+import (
+	"math/big"
+
+	"github.com/pkg/errors"
+)
+
+// Tweaking follows the key aggregation context of BIP-327: every tweak
+// t turns the key Q into g*Q + t*G, with g = 1 for a plain tweak and g = -1
+// for an x-only tweak of a Q with odd y. Signers only know X, so the
+// accumulated g and t are applied when signing:
+//
+//	s_i = r_i + e*gacc*a_i*x_i    s = sum(s_i) + e*tacc
+
+// Tweak returns the key Q + t*G, the receiver is not changed
+func (k *AggregateKey) Tweak(t *big.Int) (*AggregateKey, error) {
+	return k.tweak(t, false)
+}
+
+// XOnlyTweak returns the key P + t*G where P is the key with even y that
+// has the same x as Q, as in BIP-341 output keys
+func (k *AggregateKey) XOnlyTweak(t *big.Int) (*AggregateKey, error) {
+	return k.tweak(t, true)
+}
+
+// TaprootTweak commits scriptRoot into the key with the BIP-341 tweak
+// t = H_TapTweak(x(Q) || scriptRoot), scriptRoot may be empty for a key
+// without a script path. Only the tweaked key follows BIP-341: the sessions
+// sign with e = H(Px||Py||Rx||m) and a quadratic residue R, so their
+// signatures are not valid Taproot key-path spends of it.
+func (k *AggregateKey) TaprootTweak(scriptRoot []byte) (*AggregateKey, error) {
+	if len(scriptRoot) != 0 && len(scriptRoot) != 32 {
+		return nil, errors.New("script root must be 32 bytes")
+	}
+	hashed := taggedHash("TapTweak", scalarBytes(k.X), scriptRoot)
+	return k.XOnlyTweak(new(big.Int).SetBytes(hashed[:]))
+}
+
+func (k *AggregateKey) tweak(t *big.Int, xOnly bool) (*AggregateKey, error) {
+	if err := ValidateScalar(t); err != nil {
+		return nil, errors.Wrap(err, "tweak")
+	}
+
+	Q := k.Point.copy()
+	gacc, tacc := k.tweakAccumulators()
+	if xOnly && !hasEvenY(Q.Y) {
+		Q = Q.Neg()
+		gacc.Sub(Curve.N, gacc)
+		tacc.Sub(Curve.N, tacc).Mod(tacc, Curve.N)
+	}
+	Q = Q.Add(ScalarBaseMult(t))
+	if Q.IsInfinity() {
+		return nil, errors.New("tweaked key is the point at infinity")
+	}
+	tacc.Add(tacc, t).Mod(tacc, Curve.N)
+
+	return &AggregateKey{
+		Point:        *Q,
+		Coefficients: k.Coefficients,
+		PubKeys:      k.PubKeys,
+		gacc:         gacc,
+		tacc:         tacc,
+	}, nil
+}
+
+// copies of gacc and tacc, 1 and 0 for an untweaked key
+func (k *AggregateKey) tweakAccumulators() (gacc, tacc *big.Int) {
+	gacc, tacc = big.NewInt(1), new(big.Int)
+	if k.gacc != nil {
+		gacc.Set(k.gacc)
+	}
+	if k.tacc != nil {
+		tacc.Set(k.tacc)
+	}
+	return gacc, tacc
+}
+
+// signerFactor is gacc*a_i, the factor of x_i in the partial signature of signer i
+func (k *AggregateKey) signerFactor(i int) *big.Int {
+	gacc, _ := k.tweakAccumulators()
+	f := gacc.Mul(gacc, k.Coefficients[i])
+	return f.Mod(f, Curve.N)
+}
+
+// tweakTerm is e*tacc, added once to the sum of the partial signatures
+func (k *AggregateKey) tweakTerm(e *big.Int) *big.Int {
+	_, tacc := k.tweakAccumulators()
+	term := tacc.Mul(tacc, e)
+	return term.Mod(term, Curve.N)
+}
@@ -0,0 +1,175 @@
+package crypto
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestTweakAccumulators(t *testing.T) {
+	sessions := newTestSessions(t, 3, nil)
+	key := sessions[0].AggregateKey()
+
+	tweaked := key
+	for i := 0; i < 6; i++ {
+		_, _, tweak := GenerateKeyPair()
+		var err error
+		if i%2 == 0 {
+			tweaked, err = tweaked.XOnlyTweak(tweak)
+		} else {
+			tweaked, err = tweaked.Tweak(tweak)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Q = gacc*X + tacc*G
+	gacc, tacc := tweaked.tweakAccumulators()
+	exp := key.ScalarMult(gacc).Add(ScalarBaseMult(tacc))
+	if !tweaked.Equal(exp) {
+		t.Error("tweaked key is not gacc*X + tacc*G")
+	}
+	if key.gacc != nil || key.tacc != nil {
+		t.Error("tweaking must not change the receiver")
+	}
+
+	if _, err := key.Tweak(Curve.N); err == nil {
+		t.Error("tweak N should be rejected")
+	}
+}
+
+func TestXOnlyTweak(t *testing.T) {
+	sessions := newTestSessions(t, 3, nil)
+	key := sessions[0].AggregateKey()
+	_, _, tweak := GenerateKeyPair()
+
+	tweaked, err := key.XOnlyTweak(tweak)
+	if err != nil {
+		t.Fatal(err)
+	}
+	P, err := liftX(key.X)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !tweaked.Equal(P.Add(ScalarBaseMult(tweak))) {
+		t.Error("x-only tweak should start from the even-y key")
+	}
+
+	if _, err := key.TaprootTweak(make([]byte, 31)); err == nil {
+		t.Error("script root of 31 bytes should be rejected")
+	}
+	hashed := taggedHash("TapTweak", scalarBytes(key.X))
+	taproot, err := key.TaprootTweak(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !taproot.Equal(P.Add(ScalarBaseMult(new(big.Int).SetBytes(hashed[:])))) {
+		t.Error("taproot tweak differs from P + H_TapTweak(P)*G")
+	}
+}
+
+func TestSessionTweakedKey(t *testing.T) {
+	message := []byte("msg for signing")
+	base := newTestSessions(t, 4, message)
+	key := base[0].AggregateKey()
+
+	tweaked := key
+	for _, xOnly := range []bool{true, false, true} {
+		_, _, tweak := GenerateKeyPair()
+		var err error
+		if xOnly {
+			tweaked, err = tweaked.XOnlyTweak(tweak)
+		} else {
+			tweaked, err = tweaked.Tweak(tweak)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	sessions := make([]*Session, len(base))
+	for i, session := range base {
+		var err error
+		sessions[i], err = NewSession(tweaked, session.privKey, message)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	runCommitRound(t, sessions)
+	runRevealRound(t, sessions)
+	runPartialSignRound(t, sessions)
+
+	signature, err := sessions[0].Signature()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := VerifyMsg(signature, message, tweaked.X, tweaked.Y); !ok {
+		t.Error(err)
+	}
+	if ok, _ := VerifyMsg(signature, message, key.X, key.Y); ok {
+		t.Error("signature for the tweaked key should not verify under the untweaked key")
+	}
+}
+
+func TestMusig2SessionTweakedKey(t *testing.T) {
+	message := []byte("msg for signing")
+	var publicKeyList [][]byte
+	var privateKeyList []*big.Int
+	for i := 0; i < 3; i++ {
+		Px, Py, pk := GenerateKeyPair()
+		publicKeyList = append(publicKeyList, PointMarshal(Px, Py))
+		privateKeyList = append(privateKeyList, pk)
+	}
+	key, err := AggregateKeys(publicKeyList)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tweaked, err := key.TaprootTweak(make([]byte, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sessions := make([]*Musig2Session, len(privateKeyList))
+	for _, pk := range privateKeyList {
+		nonce, err := GenerateMusig2Nonce(pk, tweaked.Bytes(), message)
+		if err != nil {
+			t.Fatal(err)
+		}
+		session, err := NewMusig2Session(tweaked, pk, nonce, message)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sessions[session.Index()] = session
+	}
+	for _, session := range sessions {
+		for _, other := range sessions {
+			if other != session {
+				if err := session.AddNonce(other.Index(), other.nonce.R1, other.nonce.R2); err != nil {
+					t.Fatal(err)
+				}
+			}
+		}
+	}
+	partials := make([]*big.Int, len(sessions))
+	for i, session := range sessions {
+		if partials[i], err = session.PartialSign(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, session := range sessions {
+		for j, si := range partials {
+			if j != session.Index() {
+				if err := session.AddPartialSignature(j, si); err != nil {
+					t.Fatal(err)
+				}
+			}
+		}
+	}
+	signature, err := sessions[1].Signature()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := VerifyMsg(signature, message, tweaked.X, tweaked.Y); !ok {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,65 @@
+package crypto
+
+import (
+	"encoding/hex"
+	"math/big"
+
+	"github.com/pkg/errors"
+)
+
+// Every point and scalar decoded from untrusted input goes through these
+// checks before it touches curve arithmetic, Curve.Add and Curve.ScalarMult
+// silently compute garbage on points that are not on secp256k1.
+var (
+	ErrPointNotOnCurve        = errors.New("point not on curve")
+	ErrPointAtInfinity        = errors.New("point at infinity")
+	ErrFieldElementOutOfRange = errors.New("field element out of range")
+	ErrScalarOutOfRange       = errors.New("scalar out of range")
+)
+
+// ValidateFieldElement checks 0 <= x < P
+func ValidateFieldElement(x *big.Int) error {
+	if x == nil || x.Sign() < 0 || x.Cmp(Curve.P) >= 0 {
+		return ErrFieldElementOutOfRange
+	}
+	return nil
+}
+
+// ValidatePoint checks that Px, Py is a point on Curve and not the point at infinity
+func ValidatePoint(Px, Py *big.Int) error {
+	if Px == nil || Py == nil || (Px.Sign() == 0 && Py.Sign() == 0) {
+		return ErrPointAtInfinity
+	}
+	if ValidateFieldElement(Px) != nil || ValidateFieldElement(Py) != nil {
+		return ErrFieldElementOutOfRange
+	}
+	if !Curve.IsOnCurve(Px, Py) {
+		return ErrPointNotOnCurve
+	}
+	return nil
+}
+
+// ValidateScalar checks 0 <= k < N, e.g. for s of a signature
+func ValidateScalar(k *big.Int) error {
+	if k == nil || k.Sign() < 0 || k.Cmp(Curve.N) >= 0 {
+		return ErrScalarOutOfRange
+	}
+	return nil
+}
+
+// ValidateSecretScalar checks 0 < k < N, for private keys and nonces
+func ValidateSecretScalar(k *big.Int) error {
+	if k == nil || k.Sign() <= 0 || k.Cmp(Curve.N) >= 0 {
+		return ErrScalarOutOfRange
+	}
+	return nil
+}
+
+// a commitment of getHashRi is the hex of a sha256
+func validateCommitment(commitment string) error {
+	b, err := hex.DecodeString(commitment)
+	if err != nil || len(b) != 32 {
+		return errors.New("commitment must be 32 hex encoded bytes")
+	}
+	return nil
+}
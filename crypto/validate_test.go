@@ -0,0 +1,118 @@
+package crypto
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func TestValidatePoint(t *testing.T) {
+	Px, Py, _ := GenerateKeyPair()
+	if err := ValidatePoint(Px, Py); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name   string
+		Px, Py *big.Int
+		want   error
+	}{
+		{"infinity", new(big.Int), new(big.Int), ErrPointAtInfinity},
+		{"nil", nil, Py, ErrPointAtInfinity},
+		{"off curve", Px, new(big.Int).Add(Py, big.NewInt(1)), ErrPointNotOnCurve},
+		{"x not reduced", new(big.Int).Add(Px, Curve.P), Py, ErrFieldElementOutOfRange},
+		{"y not reduced", Px, new(big.Int).Add(Py, Curve.P), ErrFieldElementOutOfRange},
+	}
+	for _, c := range cases {
+		if err := ValidatePoint(c.Px, c.Py); !errors.Is(err, c.want) {
+			t.Errorf("%s: got %v, want %v", c.name, err, c.want)
+		}
+	}
+}
+
+func TestValidateScalar(t *testing.T) {
+	if err := ValidateScalar(new(big.Int)); err != nil {
+		t.Error("zero is a valid s")
+	}
+	if err := ValidateSecretScalar(new(big.Int)); !errors.Is(err, ErrScalarOutOfRange) {
+		t.Error("zero is not a valid secret")
+	}
+	if err := ValidateScalar(Curve.N); !errors.Is(err, ErrScalarOutOfRange) {
+		t.Error("N should be out of range")
+	}
+	if err := ValidateScalar(big.NewInt(-1)); !errors.Is(err, ErrScalarOutOfRange) {
+		t.Error("negative scalar should be out of range")
+	}
+}
+
+func TestPointUnmarshalRejects(t *testing.T) {
+	Px, Py, _ := GenerateKeyPair()
+
+	if _, _, err := PointUnmarshal(PointMarshal(Px, Py)[:40]); err == nil {
+		t.Error("short input should be rejected")
+	}
+	if _, _, err := PointUnmarshal(make([]byte, 64)); !errors.Is(err, ErrPointAtInfinity) {
+		t.Errorf("got %v, want %v", err, ErrPointAtInfinity)
+	}
+	offCurve := PointMarshal(Px, new(big.Int).Add(Py, big.NewInt(1)))
+	if _, _, err := PointUnmarshal(offCurve); !errors.Is(err, ErrPointNotOnCurve) {
+		t.Errorf("got %v, want %v", err, ErrPointNotOnCurve)
+	}
+
+	if _, err := AggregateKeys([][]byte{PointMarshal(Px, Py), offCurve}); !errors.Is(err, ErrPointNotOnCurve) {
+		t.Errorf("aggregating an off-curve key: got %v", err)
+	}
+}
+
+func TestVerifyRejectsOutOfRange(t *testing.T) {
+	Px, Py, pk := GenerateKeyPair()
+	message := []byte("msg for signing")
+	sig, err := SignMsg(pk, message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	Rx := new(big.Int).SetBytes(sig[:32])
+	s := new(big.Int).SetBytes(sig[32:])
+
+	// s+N and Rx+P are congruent to valid values and must not verify
+	if ok, _ := Verify(Px, Py, Rx, new(big.Int).Add(s, Curve.N), message); ok {
+		t.Error("s >= N should not verify")
+	}
+	if ok, _ := Verify(Px, Py, new(big.Int).Add(Rx, Curve.P), s, message); ok {
+		t.Error("Rx >= P should not verify")
+	}
+
+	var sigN [64]byte
+	copy(sigN[:32], sig[:32])
+	Curve.N.FillBytes(sigN[32:])
+	if ok, _ := VerifyMsg(sigN, message, Px, Py); ok {
+		t.Error("s = N should not verify")
+	}
+}
+
+func TestSessionRejectsInvalidInput(t *testing.T) {
+	sessions := newTestSessions(t, 2, []byte("msg for signing"))
+
+	if err := sessions[0].AddCommitment(1, "not a commitment"); err == nil {
+		t.Error("malformed commitment should be rejected")
+	}
+
+	// a reveal that matches its commitment is still refused when off the curve
+	Rx, Ry, _ := GenerateKeyPair()
+	Ry.Add(Ry, big.NewInt(1))
+	commitment, err := getHashRi(&Point{X: Rx, Y: Ry})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sessions[0].AddCommitment(1, commitment); err != nil {
+		t.Fatal(err)
+	}
+	if err := sessions[0].AddReveal(1, PointMarshal(Rx, Ry)); !errors.Is(err, ErrPointNotOnCurve) {
+		t.Errorf("off-curve reveal: got %v", err)
+	}
+
+	if _, err := NewSession(sessions[0].AggregateKey(), Curve.N, nil); !errors.Is(err, ErrScalarOutOfRange) {
+		t.Errorf("private key N: got %v", err)
+	}
+}
@@ -0,0 +1,347 @@
+package crypto
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"math/big"
+
+	"github.com/pkg/errors"
+)
+
+// Round messages of the commit -> reveal -> partial-sign flow on the wire.
+// The binary encoding is
+//
+//	version (1) | type (1) | session ID (32) | signer (4, big endian) | payload
+//
+// with a payload of fixed length per type: the 32-byte commitment hash, the
+// 64-byte PointMarshal encoded R_i, the 32-byte partial signature s_i or the
+// 64-byte final signature Rx||s, which a coordinator sends with signer 0.
+// Version 2 appends the 64-byte signature of the sender over everything
+// before it, see Session.SignMessage. The JSON encoding carries the same
+// fields with hex byte strings. Decoders reject unknown versions and types,
+// trailing data and invalid payloads.
+
+const (
+	// WireVersion is the version of unsigned round messages
+	WireVersion = 1
+	// WireVersionSigned is the version of round messages signed by their sender
+	WireVersionSigned = 2
+)
+
+var (
+	ErrUnsupportedVersion = errors.New("unsupported wire version")
+	ErrTrailingData       = errors.New("trailing data after message")
+)
+
+// SessionID names one signing among the cosigners, every message of the
+// signing carries it. An ID must be fresh for every run: the messages of an
+// earlier run under the same ID are signed just as well, a Session only signs
+// under the first ID it signs for.
+type SessionID [32]byte
+
+// NewSessionID draws a random SessionID
+func NewSessionID() (SessionID, error) {
+	var id SessionID
+	if _, err := io.ReadFull(rand.Reader, id[:]); err != nil {
+		return id, errors.Wrap(err, "read session id randomness")
+	}
+	return id, nil
+}
+
+// MessageType is the round a message belongs to
+type MessageType uint8
+
+const (
+	MessageCommitment MessageType = iota + 1
+	MessageReveal
+	MessagePartialSignature
+	MessageSignature
+)
+
+var messageTypeNames = map[MessageType]string{
+	MessageCommitment:       "commitment",
+	MessageReveal:           "reveal",
+	MessagePartialSignature: "partial_signature",
+	MessageSignature:        "signature",
+}
+
+func (t MessageType) String() string {
+	if name, ok := messageTypeNames[t]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+func (t MessageType) payloadLen() int {
+	if t == MessageReveal || t == MessageSignature {
+		return 64
+	}
+	return 32
+}
+
+// RoundMessage is what a signer sends to its cosigners in one round
+type RoundMessage struct {
+	Version   uint8
+	Type      MessageType
+	SessionID SessionID
+	Signer    int
+	Payload   []byte
+	// SenderSignature is only set in version WireVersionSigned
+	SenderSignature []byte
+}
+
+const wireHeaderLen = 1 + 1 + 32 + 4
+
+// NewCommitmentMessage wraps the hex commitment of Session.Commitment
+func NewCommitmentMessage(id SessionID, signer int, commitment string) (*RoundMessage, error) {
+	if err := validateCommitment(commitment); err != nil {
+		return nil, err
+	}
+	payload, _ := hex.DecodeString(commitment)
+	return newRoundMessage(MessageCommitment, id, signer, payload)
+}
+
+// NewRevealMessage wraps R_i of Session.Reveal
+func NewRevealMessage(id SessionID, signer int, Ri []byte) (*RoundMessage, error) {
+	return newRoundMessage(MessageReveal, id, signer, Ri)
+}
+
+// NewPartialSignatureMessage wraps s_i of Session.PartialSign
+func NewPartialSignatureMessage(id SessionID, signer int, si *big.Int) (*RoundMessage, error) {
+	if err := ValidateScalar(si); err != nil {
+		return nil, errors.Wrap(err, "partial signature")
+	}
+	return newRoundMessage(MessagePartialSignature, id, signer, scalarBytes(si))
+}
+
+// NewSignatureMessage wraps the final signature Rx||s
+func NewSignatureMessage(id SessionID, signature [64]byte) (*RoundMessage, error) {
+	return newRoundMessage(MessageSignature, id, 0, signature[:])
+}
+
+func newRoundMessage(t MessageType, id SessionID, signer int, payload []byte) (*RoundMessage, error) {
+	m := &RoundMessage{
+		Version:   WireVersion,
+		Type:      t,
+		SessionID: id,
+		Signer:    signer,
+		Payload:   append([]byte{}, payload...),
+	}
+	if err := m.validate(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (m *RoundMessage) validate() error {
+	switch m.Version {
+	case WireVersion:
+		if m.SenderSignature != nil {
+			return errors.New("unsigned message version with a signature")
+		}
+	case WireVersionSigned:
+		if len(m.SenderSignature) != 64 {
+			return errors.Errorf("signature must be 64 bytes, got %d", len(m.SenderSignature))
+		}
+	default:
+		return errors.Wrapf(ErrUnsupportedVersion, "version %d", m.Version)
+	}
+	if _, ok := messageTypeNames[m.Type]; !ok {
+		return errors.Errorf("unknown message type %d", m.Type)
+	}
+	if m.Signer < 0 || int64(m.Signer) > int64(^uint32(0)) {
+		return errors.Errorf("signer index %d out of range", m.Signer)
+	}
+	if len(m.Payload) != m.Type.payloadLen() {
+		return errors.Errorf("%s payload must be %d bytes, got %d", m.Type, m.Type.payloadLen(), len(m.Payload))
+	}
+	switch m.Type {
+	case MessageReveal:
+		if _, err := pointUnmarshal(m.Payload); err != nil {
+			return errors.Wrap(err, "reveal")
+		}
+	case MessagePartialSignature:
+		if err := ValidateScalar(new(big.Int).SetBytes(m.Payload)); err != nil {
+			return errors.Wrap(err, "partial signature")
+		}
+	case MessageSignature:
+		if m.Signer != 0 {
+			return errors.New("signature message must have signer 0")
+		}
+		if err := ValidateFieldElement(new(big.Int).SetBytes(m.Payload[:32])); err != nil {
+			return errors.Wrap(err, "signature Rx")
+		}
+		if err := ValidateScalar(new(big.Int).SetBytes(m.Payload[32:])); err != nil {
+			return errors.Wrap(err, "signature s")
+		}
+	}
+	return nil
+}
+
+// Commitment is the payload of a commitment message in the hex form Session.AddCommitment takes
+func (m *RoundMessage) Commitment() (string, error) {
+	if m.Type != MessageCommitment {
+		return "", errors.Errorf("%s message has no commitment", m.Type)
+	}
+	return hex.EncodeToString(m.Payload), nil
+}
+
+// Reveal is the payload of a reveal message for Session.AddReveal
+func (m *RoundMessage) Reveal() ([]byte, error) {
+	if m.Type != MessageReveal {
+		return nil, errors.Errorf("%s message has no reveal", m.Type)
+	}
+	return append([]byte{}, m.Payload...), nil
+}
+
+// PartialSignature is the payload of a partial signature message for Session.AddPartialSignature
+func (m *RoundMessage) PartialSignature() (*big.Int, error) {
+	if m.Type != MessagePartialSignature {
+		return nil, errors.Errorf("%s message has no partial signature", m.Type)
+	}
+	return new(big.Int).SetBytes(m.Payload), nil
+}
+
+// Signature is the payload of a signature message
+func (m *RoundMessage) Signature() ([64]byte, error) {
+	var ret [64]byte
+	if m.Type != MessageSignature {
+		return ret, errors.Errorf("%s message has no signature", m.Type)
+	}
+	copy(ret[:], m.Payload)
+	return ret, nil
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler
+func (m *RoundMessage) MarshalBinary() ([]byte, error) {
+	if err := m.validate(); err != nil {
+		return nil, err
+	}
+	return append(m.signedBytes(m.Version), m.SenderSignature...), nil
+}
+
+// signedBytes is the binary encoding of m as version without the signature
+func (m *RoundMessage) signedBytes(version uint8) []byte {
+	ret := make([]byte, wireHeaderLen, wireHeaderLen+len(m.Payload)+64)
+	ret[0] = version
+	ret[1] = byte(m.Type)
+	copy(ret[2:34], m.SessionID[:])
+	binary.BigEndian.PutUint32(ret[34:38], uint32(m.Signer))
+	return append(ret, m.Payload...)
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler, data must hold exactly one message
+func (m *RoundMessage) UnmarshalBinary(data []byte) error {
+	if len(data) < 2 {
+		return errors.New("message too short")
+	}
+	if data[0] != WireVersion && data[0] != WireVersionSigned {
+		return errors.Wrapf(ErrUnsupportedVersion, "version %d", data[0])
+	}
+	t := MessageType(data[1])
+	if _, ok := messageTypeNames[t]; !ok {
+		return errors.Errorf("unknown message type %d", t)
+	}
+	payloadEnd := wireHeaderLen + t.payloadLen()
+	n := payloadEnd
+	if data[0] == WireVersionSigned {
+		n += 64
+	}
+	if len(data) < n {
+		return errors.Errorf("%s message must be %d bytes, got %d", t, n, len(data))
+	}
+	if len(data) > n {
+		return ErrTrailingData
+	}
+
+	var ret RoundMessage
+	ret.Version = data[0]
+	ret.Type = t
+	copy(ret.SessionID[:], data[2:34])
+	signer := binary.BigEndian.Uint32(data[34:38])
+	if uint64(signer) > uint64(^uint(0)>>1) {
+		return errors.Errorf("signer index %d out of range", signer)
+	}
+	ret.Signer = int(signer)
+	ret.Payload = append([]byte{}, data[wireHeaderLen:payloadEnd]...)
+	if ret.Version == WireVersionSigned {
+		ret.SenderSignature = append([]byte{}, data[payloadEnd:]...)
+	}
+	if err := ret.validate(); err != nil {
+		return err
+	}
+	*m = ret
+	return nil
+}
+
+type roundMessageJSON struct {
+	Version         uint8  `json:"version"`
+	Type            string `json:"type"`
+	SessionID       string `json:"session_id"`
+	Signer          int    `json:"signer"`
+	Payload         string `json:"payload"`
+	SenderSignature string `json:"sender_signature,omitempty"`
+}
+
+// MarshalJSON implements json.Marshaler
+func (m *RoundMessage) MarshalJSON() ([]byte, error) {
+	if err := m.validate(); err != nil {
+		return nil, err
+	}
+	return json.Marshal(roundMessageJSON{
+		Version:         m.Version,
+		Type:            m.Type.String(),
+		SessionID:       hex.EncodeToString(m.SessionID[:]),
+		Signer:          m.Signer,
+		Payload:         hex.EncodeToString(m.Payload),
+		SenderSignature: hex.EncodeToString(m.SenderSignature),
+	})
+}
+
+// UnmarshalJSON implements json.Unmarshaler, unknown fields and data after
+// the object are rejected
+func (m *RoundMessage) UnmarshalJSON(data []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	var v roundMessageJSON
+	if err := dec.Decode(&v); err != nil {
+		return errors.Wrap(err, "decode round message")
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return ErrTrailingData
+	}
+	if v.Version != WireVersion && v.Version != WireVersionSigned {
+		return errors.Wrapf(ErrUnsupportedVersion, "version %d", v.Version)
+	}
+
+	ret := RoundMessage{Version: v.Version, Signer: v.Signer}
+	for t, name := range messageTypeNames {
+		if name == v.Type {
+			ret.Type = t
+		}
+	}
+	id, err := hex.DecodeString(v.SessionID)
+	if err != nil || len(id) != len(ret.SessionID) {
+		return errors.New("session id must be 32 hex encoded bytes")
+	}
+	copy(ret.SessionID[:], id)
+	if ret.Payload, err = hex.DecodeString(v.Payload); err != nil {
+		return errors.Wrap(err, "decode payload")
+	}
+	if v.Version == WireVersionSigned {
+		if ret.SenderSignature, err = hex.DecodeString(v.SenderSignature); err != nil {
+			return errors.Wrap(err, "decode signature")
+		}
+	} else if v.SenderSignature != "" {
+		return errors.New("unsigned message version with a signature")
+	}
+	if err := ret.validate(); err != nil {
+		return err
+	}
+	*m = ret
+	return nil
+}
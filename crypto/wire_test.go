@@ -0,0 +1,165 @@
+package crypto
+
+import (
+	"encoding/json"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func newTestRoundMessages(t *testing.T) []*RoundMessage {
+	id, err := NewSessionID()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sessions := newTestSessions(t, 2, []byte("msg for signing"))
+	runCommitRound(t, sessions)
+	runRevealRound(t, sessions)
+	session := sessions[1]
+	Ri, err := session.Reveal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	si, err := session.PartialSign()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	commitment, err := NewCommitmentMessage(id, 1, session.Commitment())
+	if err != nil {
+		t.Fatal(err)
+	}
+	reveal, err := NewRevealMessage(id, 1, Ri)
+	if err != nil {
+		t.Fatal(err)
+	}
+	partial, err := NewPartialSignatureMessage(id, 1, si)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if c, _ := commitment.Commitment(); c != session.Commitment() {
+		t.Error("commitment changed in the message")
+	}
+	if R, _ := reveal.Reveal(); string(R) != string(Ri) {
+		t.Error("reveal changed in the message")
+	}
+	if s, _ := partial.PartialSignature(); s.Cmp(si) != 0 {
+		t.Error("partial signature changed in the message")
+	}
+	if _, err := partial.Reveal(); err == nil {
+		t.Error("partial signature message should have no reveal")
+	}
+	signature, err := SignMsg(session.privKey, []byte("msg for signing"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	final, err := NewSignatureMessage(id, signature)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sig, _ := final.Signature(); sig != signature {
+		t.Error("signature changed in the message")
+	}
+	return []*RoundMessage{commitment, reveal, partial, final}
+}
+
+func TestRoundMessageBinary(t *testing.T) {
+	for _, m := range newTestRoundMessages(t) {
+		data, err := m.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(data) != wireHeaderLen+m.Type.payloadLen() {
+			t.Errorf("%s message of %d bytes", m.Type, len(data))
+		}
+		var decoded RoundMessage
+		if err := decoded.UnmarshalBinary(data); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(&decoded, m) {
+			t.Errorf("%s message changed after decoding", m.Type)
+		}
+
+		if err := decoded.UnmarshalBinary(append(data, 0)); err != ErrTrailingData {
+			t.Errorf("trailing byte, got %v", err)
+		}
+		if err := decoded.UnmarshalBinary(data[:len(data)-1]); err == nil {
+			t.Error("short message should be rejected")
+		}
+		other := append([]byte{}, data...)
+		other[0] = WireVersionSigned + 1
+		if err := decoded.UnmarshalBinary(other); !errors.Is(err, ErrUnsupportedVersion) {
+			t.Errorf("unknown version, got %v", err)
+		}
+		other[0], other[1] = WireVersion, 9
+		if err := decoded.UnmarshalBinary(other); err == nil {
+			t.Error("unknown type should be rejected")
+		}
+	}
+
+	// an off-curve R_i or an s_i >= N is not a valid payload
+	reveal := newTestRoundMessages(t)[1]
+	data, _ := reveal.MarshalBinary()
+	data[len(data)-1] ^= 1
+	if err := new(RoundMessage).UnmarshalBinary(data); err == nil {
+		t.Error("off-curve reveal should be rejected")
+	}
+	partial := newTestRoundMessages(t)[2]
+	partial.Payload = scalarBytes(Curve.N)
+	if _, err := partial.MarshalBinary(); err == nil {
+		t.Error("partial signature N should be rejected")
+	}
+}
+
+func TestRoundMessageJSON(t *testing.T) {
+	for _, m := range newTestRoundMessages(t) {
+		data, err := json.Marshal(m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var decoded RoundMessage
+		if err := json.Unmarshal(data, &decoded); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(&decoded, m) {
+			t.Errorf("%s message changed after decoding", m.Type)
+		}
+		if err := decoded.UnmarshalJSON(append(data, []byte(" {}")...)); err != ErrTrailingData {
+			t.Errorf("trailing object, got %v", err)
+		}
+	}
+
+	id := make([]byte, 64)
+	for i := range id {
+		id[i] = 'a'
+	}
+	valid := `{"version":1,"type":"partial_signature","session_id":"` + string(id) + `","signer":3,"payload":"` + string(id) + `"}`
+	var m RoundMessage
+	if err := json.Unmarshal([]byte(valid), &m); err != nil {
+		t.Fatal(err)
+	}
+	if m.Signer != 3 || m.Type != MessagePartialSignature {
+		t.Errorf("decoded %+v", m)
+	}
+	for _, invalid := range []string{
+		`{"version":2,"type":"partial_signature","session_id":"` + string(id) + `","signer":3,"payload":"` + string(id) + `"}`,
+		`{"version":3,"type":"partial_signature","session_id":"` + string(id) + `","signer":3,"payload":"` + string(id) + `"}`,
+		`{"version":1,"type":"partial_signature","session_id":"` + string(id) + `","signer":3,"payload":"` + string(id) + `","sender_signature":"` + string(id) + string(id) + `"}`,
+		`{"version":1,"type":"nonce","session_id":"` + string(id) + `","signer":3,"payload":"` + string(id) + `"}`,
+		`{"version":1,"type":"partial_signature","session_id":"aa","signer":3,"payload":"` + string(id) + `"}`,
+		`{"version":1,"type":"partial_signature","session_id":"` + string(id) + `","signer":-1,"payload":"` + string(id) + `"}`,
+		`{"version":1,"type":"partial_signature","session_id":"` + string(id) + `","signer":3,"payload":"aa"}`,
+		`{"version":1,"type":"partial_signature","session_id":"` + string(id) + `","signer":3,"payload":"` + string(id) + `","extra":1}`,
+	} {
+		if err := json.Unmarshal([]byte(invalid), &m); err == nil {
+			t.Errorf("%s should be rejected", invalid)
+		}
+	}
+
+	if _, err := NewPartialSignatureMessage(SessionID{}, 0, big.NewInt(0).Set(Curve.N)); err == nil {
+		t.Error("partial signature N should be rejected")
+	}
+}
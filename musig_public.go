@@ -1,200 +1,412 @@
 package musig_go
 
 import (
-	"crypto/ecdsa"
-	"crypto/rand"
-	"crypto/sha256"
-	"encoding/hex"
-	"errors"
-	"fmt"
-	"github.com/btcsuite/btcd/btcec"
 	"math/big"
+
+	"github.com/renne444/musig-go/crypto"
 )
 
+// The root package is the stable entry point of the library, everything here
+// forwards to the crypto package. It re-exports the whole exported API of
+// crypto except TempMusig, a debugging leftover.
+
 var (
-	Curve = btcec.S256()
+	Curve = crypto.Curve
+
+	ErrPointNotOnCurve        = crypto.ErrPointNotOnCurve
+	ErrPointAtInfinity        = crypto.ErrPointAtInfinity
+	ErrFieldElementOutOfRange = crypto.ErrFieldElementOutOfRange
+	ErrScalarOutOfRange       = crypto.ErrScalarOutOfRange
+	ErrInvalidChild           = crypto.ErrInvalidChild
+	ErrNonceReused            = crypto.ErrNonceReused
+	ErrUnsupportedVersion     = crypto.ErrUnsupportedVersion
+	ErrTrailingData           = crypto.ErrTrailingData
+)
+
+const (
+	HardenedKeyStart = crypto.HardenedKeyStart
+
+	WireVersion             = crypto.WireVersion
+	WireVersionSigned       = crypto.WireVersionSigned
+	MessageCommitment       = crypto.MessageCommitment
+	MessageReveal           = crypto.MessageReveal
+	MessagePartialSignature = crypto.MessagePartialSignature
+	MessageSignature        = crypto.MessageSignature
+)
+
+type (
+	Point                 = crypto.Point
+	PrivateKey            = crypto.PrivateKey
+	PublicKey             = crypto.PublicKey
+	Signature             = crypto.Signature
+	AggregateKey          = crypto.AggregateKey
+	KeyAggOption          = crypto.KeyAggOption
+	ExtendedKey           = crypto.ExtendedKey
+	Session               = crypto.Session
+	SessionOption         = crypto.SessionOption
+	Musig2Nonce           = crypto.Musig2Nonce
+	Musig2Session         = crypto.Musig2Session
+	NonceStore            = crypto.NonceStore
+	MemoryNonceStore      = crypto.MemoryNonceStore
+	FileNonceStore        = crypto.FileNonceStore
+	PartialSignatureError = crypto.PartialSignatureError
+	SessionID             = crypto.SessionID
+	MessageType           = crypto.MessageType
+	RoundMessage          = crypto.RoundMessage
+	BlameProof            = crypto.BlameProof
+	FrostGroup            = crypto.FrostGroup
+	FrostKeyShare         = crypto.FrostKeyShare
+	FrostCommitment       = crypto.FrostCommitment
+	FrostNonce            = crypto.FrostNonce
+	DKG                   = crypto.DKG
+	DKGCommitment         = crypto.DKGCommitment
+	DKGShare              = crypto.DKGShare
+	DKGComplaint          = crypto.DKGComplaint
+	DKGAnswer             = crypto.DKGAnswer
+	AdaptorSignature      = crypto.AdaptorSignature
+	BatchItem             = crypto.BatchItem
+	HalfAggItem           = crypto.HalfAggItem
+	HalfAggSignature      = crypto.HalfAggSignature
+	BatchError            = crypto.BatchError
 )
 
 func GenerateKeyPair() (Px, Py, pk *big.Int) {
-	key, err := ecdsa.GenerateKey(Curve, rand.Reader)
-	if err != nil {
-		fmt.Println(err)
-	}
-	return key.X, key.Y, key.D
+	return crypto.GenerateKeyPair()
 }
 
-func PointMarshal(Px, Py *big.Int) (ret []byte) {
+func NewPrivateKey() (*PrivateKey, error) {
+	return crypto.NewPrivateKey()
+}
 
-	//bug 3. 曾没有考虑点的长度，没有将每个点的长度定死在32，导致有概率Px或Py太小位数小于32，从而导致解码的时候失败。
-	ret = []byte{}
-	bPx, bPy := [32]byte{}, [32]byte{}
-	copy(bPx[32-len(Px.Bytes()):], Px.Bytes())
-	copy(bPy[32-len(Py.Bytes()):], Py.Bytes())
+func ParsePrivateKey(src []byte) (*PrivateKey, error) {
+	return crypto.ParsePrivateKey(src)
+}
 
-	ret = append(ret, bPx[:]...)
-	ret = append(ret, bPy[:]...)
+func NewPrivateKeyFromScalar(D *big.Int) (*PrivateKey, error) {
+	return crypto.NewPrivateKeyFromScalar(D)
+}
 
-	return ret
+func NewPublicKey(X, Y *big.Int) (*PublicKey, error) {
+	return crypto.NewPublicKey(X, Y)
+}
 
+func ParsePublicKey(src []byte) (*PublicKey, error) {
+	return crypto.ParsePublicKey(src)
+}
+
+func ParseSignature(src []byte) (*Signature, error) {
+	return crypto.ParseSignature(src)
+}
+
+func NewSignature(Rx, S *big.Int) (*Signature, error) {
+	return crypto.NewSignature(Rx, S)
+}
+
+func PointMarshal(Px, Py *big.Int) []byte {
+	return crypto.PointMarshal(Px, Py)
+}
+
+func PointMarshalCompressed(Px, Py *big.Int) []byte {
+	return crypto.PointMarshalCompressed(Px, Py)
+}
+
+func PointMarshalUncompressed(Px, Py *big.Int) []byte {
+	return crypto.PointMarshalUncompressed(Px, Py)
 }
 
 func PointUnmarshal(src []byte) (Px, Py *big.Int, err error) {
-	bPx := src[:32]
-	bPy := src[32:]
+	return crypto.PointUnmarshal(src)
+}
 
-	if len(bPx) != 32 || len(bPy) != 32 {
-		return nil, nil,
-			errors.New(fmt.Sprintf("Point Unmarshal Error because of length, with bPx = %d, bPy = %d",
-				len(bPx), len(bPy)))
+func PointDecode(src []byte) (Px, Py *big.Int, err error) {
+	return crypto.PointDecode(src)
+}
 
-	}
+func NewPoint(x, y *big.Int) (*Point, error) {
+	return crypto.NewPoint(x, y)
+}
 
-	Px = big.NewInt(0).SetBytes(bPx)
-	Py = big.NewInt(0).SetBytes(bPy)
+func ParsePoint(src []byte) (*Point, error) {
+	return crypto.ParsePoint(src)
+}
 
-	return Px, Py, nil
+func ScalarBaseMult(k *big.Int) *Point {
+	return crypto.ScalarBaseMult(k)
 }
 
-func getChallengeFactorList(Points [][]byte) []*big.Int {
-	var L []byte
-	for _, point := range Points {
-		L = append(L, point...)
-	}
+func Infinity() *Point {
+	return crypto.Infinity()
+}
 
-	var challengeFactorList []*big.Int
-	for _, point := range Points {
-		var hashPayload []byte
-		copy(hashPayload, L[:])
-		hashPayload = append(hashPayload, point...)
-		hashedBytes := sha256.Sum256(hashPayload)
-		challengeFactor := new(big.Int).SetBytes(hashedBytes[:])
-		challengeFactor.Mod(challengeFactor, Curve.N)
+func ValidatePoint(Px, Py *big.Int) error {
+	return crypto.ValidatePoint(Px, Py)
+}
 
-		challengeFactorList = append(challengeFactorList, challengeFactor)
-	}
-	return challengeFactorList
+func ValidateScalar(k *big.Int) error {
+	return crypto.ValidateScalar(k)
 }
 
-func getHash(Px, Py, Rx *big.Int, message []byte) *big.Int {
-	payload := append(Px.Bytes(), Py.Bytes()...)
-	payload = append(payload, Rx.Bytes()...)
-	payload = append(payload, message...)
-	hashed := sha256.Sum256(payload)
-	i := new(big.Int).SetBytes(hashed[:])
-	return i.Mod(i, Curve.N)
+func ValidateSecretScalar(k *big.Int) error {
+	return crypto.ValidateSecretScalar(k)
 }
 
-func SimpleMusigTest() {
-	var privateKeyList []*big.Int
-	var privateRandomList []*big.Int
+func ValidateFieldElement(x *big.Int) error {
+	return crypto.ValidateFieldElement(x)
+}
 
-	for i := 0; i < 10; i++ {
-		_, _, pk := GenerateKeyPair()
-		_, _, r := GenerateKeyPair()
+func DeriveNonce(privKey *big.Int, message, aggKey []byte) (*big.Int, error) {
+	return crypto.DeriveNonce(privKey, message, aggKey)
+}
 
-		privateKeyList = append(privateKeyList, pk)
-		privateRandomList = append(privateRandomList, r)
-	}
+func SignMsg(pk *big.Int, message []byte) ([64]byte, error) {
+	return crypto.SignMsg(pk, message)
+}
 
-	//message := []byte("message")
-	Rx, Ry := new(big.Int), new(big.Int)
-	Px, Py := new(big.Int), new(big.Int)
-	var publicKeyList [][]byte
-	var publicRandomList [][]byte
+func SignWithNonce(pk, r *big.Int, message []byte) ([64]byte, error) {
+	return crypto.SignWithNonce(pk, r, message)
+}
 
-	for i := 0; i < 10; i++ {
-		privateKey := privateKeyList[i]
-		privateRandom := privateRandomList[i]
+// Deprecated: use SignMsg.
+func Sign(pk, r *big.Int, message []byte) ([64]byte, error) {
+	return crypto.Sign(pk, r, message)
+}
 
-		PiX, PiY := Curve.ScalarBaseMult(privateKey.Bytes())
-		RiX, RiY := Curve.ScalarBaseMult(privateRandom.Bytes())
+func Verify(Px, Py, Rx, s *big.Int, message []byte) (bool, error) {
+	return crypto.Verify(Px, Py, Rx, s, message)
+}
 
-		Rx, Ry = Curve.Add(Rx, Ry, RiX, RiY)
-		Px, Py = Curve.Add(Px, Py, PiX, PiY)
+func VerifyMsg(signature [64]byte, message []byte, Px, Py *big.Int) (bool, error) {
+	return crypto.VerifyMsg(signature, message, Px, Py)
+}
 
-		bytePi := PointMarshal(PiX, PiY)
-		byteRi := PointMarshal(RiX, RiY)
+func VerifyBatch(items []BatchItem) (bool, error) {
+	return crypto.VerifyBatch(items)
+}
 
-		publicKeyList = append(publicKeyList, bytePi)
-		publicRandomList = append(publicRandomList, byteRi)
-	}
+func HalfAggregate(items []BatchItem) (*HalfAggSignature, error) {
+	return crypto.HalfAggregate(items)
+}
 
-	challengeFactorList := getChallengeFactorList(publicKeyList)
+func IncrementalHalfAggregate(agg *HalfAggSignature, prior []HalfAggItem, items []BatchItem) (*HalfAggSignature, error) {
+	return crypto.IncrementalHalfAggregate(agg, prior, items)
+}
 
-	var memberPrivateKeyList []*big.Int
-	var memberPublicKeyList [][]byte
+func VerifyHalfAggregate(items []HalfAggItem, agg *HalfAggSignature) (bool, error) {
+	return crypto.VerifyHalfAggregate(items, agg)
+}
 
-	for i := 0; i < 10; i++ {
-		mpk := new(big.Int).Mul(privateKeyList[i], challengeFactorList[i])
-		PiX, PiY, _ := PointUnmarshal(publicKeyList[i])
-		mPiX, mPiY := Curve.ScalarMult(PiX, PiY, challengeFactorList[i].Bytes())
+func ParseHalfAggSignature(b []byte) (*HalfAggSignature, error) {
+	return crypto.ParseHalfAggSignature(b)
+}
 
-		memberPrivateKeyList = append(memberPrivateKeyList, mpk)
-		memberPublicKeyList = append(memberPublicKeyList, PointMarshal(mPiX, mPiY))
-	}
+func AdaptorSign(pk *big.Int, T []byte, message []byte) (*AdaptorSignature, error) {
+	return crypto.AdaptorSign(pk, T, message)
+}
 
-	aggMemPx, aggMemPy := new(big.Int).SetInt64(0), new(big.Int).SetInt64(0)
-	aggPx, aggPy := new(big.Int).SetInt64(0), new(big.Int).SetInt64(0)
-	aggRx, aggRy := new(big.Int).SetInt64(0), new(big.Int).SetInt64(0)
+func AdaptorVerify(Px, Py *big.Int, T []byte, message []byte, sig *AdaptorSignature) (bool, error) {
+	return crypto.AdaptorVerify(Px, Py, T, message, sig)
+}
+
+func SignBIP340(pk *big.Int, message []byte, auxRand []byte) ([64]byte, error) {
+	return crypto.SignBIP340(pk, message, auxRand)
+}
+
+func VerifyBIP340(pubKey []byte, message []byte, signature [64]byte) (bool, error) {
+	return crypto.VerifyBIP340(pubKey, message, signature)
+}
+
+func XOnlyPubKey(pk *big.Int) [32]byte {
+	return crypto.XOnlyPubKey(pk)
+}
+
+func SortKeys(pubkeys [][]byte) [][]byte {
+	return crypto.SortKeys(pubkeys)
+}
+
+func KeepKeyOrder() KeyAggOption {
+	return crypto.KeepKeyOrder()
+}
+
+func AggregateKeys(pubkeys [][]byte, opts ...KeyAggOption) (*AggregateKey, error) {
+	return crypto.AggregateKeys(pubkeys, opts...)
+}
+
+func NewExtendedKey(key *AggregateKey) *ExtendedKey {
+	return crypto.NewExtendedKey(key)
+}
+
+func NewSession(key *AggregateKey, privKey *big.Int, message []byte, opts ...SessionOption) (*Session, error) {
+	return crypto.NewSession(key, privKey, message, opts...)
+}
+
+func WithAdaptor(T []byte) SessionOption {
+	return crypto.WithAdaptor(T)
+}
+
+func WithNonce(r *big.Int) SessionOption {
+	return crypto.WithNonce(r)
+}
+
+func NewSessionID() (SessionID, error) {
+	return crypto.NewSessionID()
+}
+
+func NewCommitmentMessage(id SessionID, signer int, commitment string) (*RoundMessage, error) {
+	return crypto.NewCommitmentMessage(id, signer, commitment)
+}
+
+func NewRevealMessage(id SessionID, signer int, Ri []byte) (*RoundMessage, error) {
+	return crypto.NewRevealMessage(id, signer, Ri)
+}
+
+func NewPartialSignatureMessage(id SessionID, signer int, si *big.Int) (*RoundMessage, error) {
+	return crypto.NewPartialSignatureMessage(id, signer, si)
+}
+
+func NewSignatureMessage(id SessionID, signature [64]byte) (*RoundMessage, error) {
+	return crypto.NewSignatureMessage(id, signature)
+}
+
+func VerifyCommitment(commitment string, Ri []byte) (bool, error) {
+	return crypto.VerifyCommitment(commitment, Ri)
+}
+
+func CombinePartialSignatures(key *AggregateKey, Rs [][]byte, partials []*big.Int, message []byte) ([64]byte, error) {
+	return crypto.CombinePartialSignatures(key, Rs, partials, message)
+}
+
+func CombinePartialPreSignatures(key *AggregateKey, Rs [][]byte, T []byte, partials []*big.Int, message []byte) (*AdaptorSignature, error) {
+	return crypto.CombinePartialPreSignatures(key, Rs, T, partials, message)
+}
+
+func NewRevealBlameProof(key *AggregateKey, message, adaptor []byte, commitment, reveal *RoundMessage) (*BlameProof, error) {
+	return crypto.NewRevealBlameProof(key, message, adaptor, commitment, reveal)
+}
+
+func NewPartialBlameProof(key *AggregateKey, message, adaptor []byte, reveals []*RoundMessage, partial *RoundMessage) (*BlameProof, error) {
+	return crypto.NewPartialBlameProof(key, message, adaptor, reveals, partial)
+}
+
+func WithNonceStore(store NonceStore) SessionOption {
+	return crypto.WithNonceStore(store)
+}
+
+func RestoreSession(state []byte, privKey *big.Int, opts ...SessionOption) (*Session, error) {
+	return crypto.RestoreSession(state, privKey, opts...)
+}
+
+func NewMemoryNonceStore() *MemoryNonceStore {
+	return crypto.NewMemoryNonceStore()
+}
+
+func NewFileNonceStore(dir string) (*FileNonceStore, error) {
+	return crypto.NewFileNonceStore(dir)
+}
+
+func GenerateMusig2Nonce(privKey *big.Int, aggKey, message []byte) (*Musig2Nonce, error) {
+	return crypto.GenerateMusig2Nonce(privKey, aggKey, message)
+}
+
+func NewMusig2Session(key *AggregateKey, privKey *big.Int, nonce *Musig2Nonce, message []byte, opts ...SessionOption) (*Musig2Session, error) {
+	return crypto.NewMusig2Session(key, privKey, nonce, message, opts...)
+}
+
+func FrostDealer(threshold, n int) (*FrostGroup, []*FrostKeyShare, error) {
+	return crypto.FrostDealer(threshold, n)
+}
+
+func NewDKG(id, threshold int, identity *big.Int, peers [][]byte) (*DKG, error) {
+	return crypto.NewDKG(id, threshold, identity, peers)
+}
+
+func GenerateFrostNonce(share *FrostKeyShare, message []byte) (*FrostNonce, error) {
+	return crypto.GenerateFrostNonce(share, message)
+}
+
+func FrostSign(share *FrostKeyShare, nonce *FrostNonce, commitments []FrostCommitment, message []byte) (*big.Int, error) {
+	return crypto.FrostSign(share, nonce, commitments, message)
+}
+
+func VerifyPartial(si *big.Int, Ri, Pi []byte, ai, e *big.Int) (bool, error) {
+	return crypto.VerifyPartial(si, Ri, Pi, ai, e)
+}
+
+func AggregatePartialSignatures(key *AggregateKey, Rs [][]byte, partials []*big.Int, message []byte) (*big.Int, error) {
+	return crypto.AggregatePartialSignatures(key, Rs, partials, message)
+}
+
+// SimpleMusigTest runs a 10-of-10 MuSig session in process and returns the aggregate key and the signature of message
+func SimpleMusigTest(message []byte) (*AggregateKey, [64]byte, error) {
+	var privateKeyList []*big.Int
+	var publicKeyList [][]byte
 	for i := 0; i < 10; i++ {
-		memPiX, memPiY, _ := PointUnmarshal(memberPublicKeyList[i])
-		aggMemPx, aggMemPy = Curve.Add(aggMemPx, aggMemPy, memPiX, memPiY)
-		RiX, RiY, err := PointUnmarshal(publicRandomList[i])
+		key, err := NewPrivateKey()
 		if err != nil {
-			fmt.Errorf(err.Error())
+			return nil, [64]byte{}, err
 		}
-		aggRx, aggRy = Curve.Add(aggRx, aggRy, RiX, RiY)
-		PiX, PiY, _ := PointUnmarshal(publicKeyList[i])
-		aggPx, aggPy = Curve.Add(aggPx, aggPy, PiX, PiY)
+		privateKeyList = append(privateKeyList, key.D)
+		publicKeyList = append(publicKeyList, key.Public().Bytes())
 	}
 
-	message := []byte("msg for signing")
-	e := getHash(aggPx, aggPy, aggRx, message)
-	s := new(big.Int).SetInt64(0)
-	for i := 0; i < 10; i++ {
-		si := new(big.Int).SetInt64(0)
-		r0 := privateRandomList[i]
-		r := new(big.Int).Set(r0)
+	aggKey, err := AggregateKeys(publicKeyList)
+	if err != nil {
+		return nil, [64]byte{}, err
+	}
 
-		if big.Jacobi(aggRy, Curve.P) != 1 {
-			r.Sub(Curve.N, r)
+	var sessions []*Session
+	for _, pk := range privateKeyList {
+		session, err := NewSession(aggKey, pk, message)
+		if err != nil {
+			return nil, [64]byte{}, err
 		}
-
-		si.Set(e)
-		si.Mul(si, memberPrivateKeyList[i])
-		si.Add(si, r)
-
-		s.Add(s, si)
+		sessions = append(sessions, session)
 	}
 
-	//bug 1. 曾将aggRy的变形部分写进了循环，导致本来只需要变形一次，却变成了10次，很危险的bug，因为如果加密主体数量是奇数可能这个bug会被隐藏
-//	if big.Jacobi(aggRy, Curve.P) != 1 {
-//		aggRy.Sub(Curve.P, aggRy)
-//	}
-
-	/////////////////Verify////////////////////
-
-	//	t.Log(hex.EncodeToString(s.Bytes()))
-
-	sGx, sGy := Curve.ScalarBaseMult(s.Bytes())
-	ePx, ePy := Curve.ScalarMult(aggMemPx, aggMemPy, e.Bytes())
-	ePy.Sub(Curve.P, ePy)
-	cRx, cRy := Curve.Add(sGx, sGy, ePx, ePy)
+	for _, session := range sessions {
+		for _, other := range sessions {
+			if other == session {
+				continue
+			}
+			if err := session.AddCommitment(other.Index(), other.Commitment()); err != nil {
+				return nil, [64]byte{}, err
+			}
+		}
+	}
 
-	//bug 2. 曾将验证过程写成和Curve.N的Jacobi
-	fmt.Println(big.Jacobi(cRy, Curve.P))
+	for _, session := range sessions {
+		for _, other := range sessions {
+			if other == session {
+				continue
+			}
+			Ri, err := other.Reveal()
+			if err != nil {
+				return nil, [64]byte{}, err
+			}
+			if err := session.AddReveal(other.Index(), Ri); err != nil {
+				return nil, [64]byte{}, err
+			}
+		}
+	}
 
-	fmt.Println(hex.EncodeToString(sGx.Bytes()))
-	fmt.Println(hex.EncodeToString(sGy.Bytes()))
-	fmt.Println(hex.EncodeToString(ePx.Bytes()))
-	fmt.Println(hex.EncodeToString(ePy.Bytes()))
-	fmt.Println(hex.EncodeToString(aggRx.Bytes()))
-	fmt.Println(hex.EncodeToString(cRx.Bytes()))
-	fmt.Println(hex.EncodeToString(cRy.Bytes()))
+	// every session collects the partial signatures, the first one aggregates
+	for _, session := range sessions {
+		si, err := session.PartialSign()
+		if err != nil {
+			return nil, [64]byte{}, err
+		}
+		for _, other := range sessions {
+			if other == session {
+				continue
+			}
+			if err := other.AddPartialSignature(session.Index(), si); err != nil {
+				return nil, [64]byte{}, err
+			}
+		}
+	}
 
-	if (cRx.Sign() == 0 && cRy.Sign() == 0) || big.Jacobi(cRy, Curve.P) != 1 || cRx.Cmp(aggRx) != 0 {
-		fmt.Println("verification failed")
-	} else {
-		fmt.Println("verification succeeded")
+	signature, err := sessions[0].Signature()
+	if err != nil {
+		return nil, [64]byte{}, err
 	}
+	return aggKey, signature, nil
 }
@@ -3,5 +3,44 @@ package musig_go
 import "testing"
 
 func TestMainFunc(m *testing.T) {
-	SimpleMusigTest()
+	message := []byte("msg for signing")
+	aggKey, signature, err := SimpleMusigTest(message)
+	if err != nil {
+		m.Fatal(err)
+	}
+	if ok, err := VerifyMsg(signature, message, aggKey.X, aggKey.Y); !ok {
+		m.Error(err)
+	}
+}
+
+func TestFacadeSignWithNonce(t *testing.T) {
+	Px, Py, pk := GenerateKeyPair()
+	key, err := NewPrivateKeyFromScalar(pk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	message := []byte("msg for signing")
+	r, err := DeriveNonce(pk, message, PointMarshal(Px, Py))
+	if err != nil {
+		t.Fatal(err)
+	}
+	signature, err := SignWithNonce(key.D, r, message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := VerifyMsg(signature, message, Px, Py); !ok {
+		t.Error(err)
+	}
+
+	Qx, Qy, err := PointDecode(PointMarshalCompressed(Px, Py))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub, err := NewPublicKey(Qx, Qy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !pub.Equal(&key.Point) {
+		t.Error("public key changed after the compressed encoding")
+	}
 }